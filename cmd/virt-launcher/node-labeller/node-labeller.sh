@@ -44,3 +44,6 @@ if [ "$ARCH" == "x86_64" ]; then
 fi
 
 virsh capabilities > /var/lib/kubevirt-node-labeller/capabilities.xml
+
+# the versions only help debugging, the labelling must not depend on them
+virsh version > /var/lib/kubevirt-node-labeller/virsh_version.txt || true
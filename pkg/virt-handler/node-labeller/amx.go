@@ -0,0 +1,76 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// amxFeatures are the advanced matrix extensions of newer Intel cpus, e.g. Sapphire Rapids. Their large
+// register state has to be supported by the migration target as well, hence they are migration-sensitive.
+var amxFeatures = map[string]bool{
+	"amx-bf16": true,
+	"amx-int8": true,
+	"amx-tile": true,
+}
+
+// addAMXLabels labels the AMX features supported by the host, unless they are denied
+func (n *NodeLabeller) addAMXLabels(labels *NodeLabels, features cpuFeatures) {
+	labelled := make(map[string]bool, len(labels.Features))
+	for _, feature := range labels.Features {
+		labelled[feature] = true
+	}
+	for _, feature := range n.supportedAMXFeatures(features) {
+		if !labelled[feature] {
+			labels.Features = append(labels.Features, feature)
+		}
+	}
+}
+
+// supportedAMXFeatures returns the sorted AMX features among the given features, without the denied ones
+func (n *NodeLabeller) supportedAMXFeatures(features cpuFeatures) []string {
+	supported := []string{}
+	for feature := range features {
+		name := strings.ToLower(feature)
+		if amxFeatures[name] && !n.deniedFeatures[name] {
+			supported = append(supported, name)
+		}
+	}
+	sort.Strings(supported)
+	return supported
+}
+
+// setMigrationSensitiveFeaturesAnnotation lists the migration-sensitive features of the node, VMs using them
+// can only migrate to nodes supporting them as well. The annotation is removed when there are none.
+func (n *NodeLabeller) setMigrationSensitiveFeaturesAnnotation(node *v1.Node, features cpuFeatures) {
+	sensitive := n.supportedAMXFeatures(features)
+	if len(sensitive) == 0 {
+		delete(node.Annotations, kubevirtv1.MigrationSensitiveFeaturesAnnotation)
+		return
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.MigrationSensitiveFeaturesAnnotation] = strings.Join(sensitive, ",")
+}
@@ -0,0 +1,66 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("AMX features", func() {
+
+	var n *NodeLabeller
+
+	BeforeEach(func() {
+		n = &NodeLabeller{logger: log.DefaultLogger(), volumePath: "testdata/sapphirerapids"}
+		Expect(n.loadHostSupportedFeatures()).To(Succeed())
+	})
+
+	It("should label the AMX features of a Sapphire Rapids host", func() {
+		labels := &NodeLabels{}
+		n.addAMXLabels(labels, n.getSupportedCpuFeatures())
+		Expect(labels.Features).To(ConsistOf("amx-bf16", "amx-int8", "amx-tile"))
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.CPUFeatureLabel+"amx-tile", "true"))
+	})
+
+	It("should annotate the AMX features as migration-sensitive", func() {
+		node := &v1.Node{}
+		n.setMigrationSensitiveFeaturesAnnotation(node, n.getSupportedCpuFeatures())
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.MigrationSensitiveFeaturesAnnotation, "amx-bf16,amx-int8,amx-tile"))
+
+		n.setMigrationSensitiveFeaturesAnnotation(node, cpuFeatures{"vmx": true, "avx512f": true})
+		Expect(node.Annotations).ToNot(HaveKey(kubevirtv1.MigrationSensitiveFeaturesAnnotation))
+	})
+
+	It("should not label or annotate denied AMX features", func() {
+		n.deniedFeatures = map[string]bool{"amx-int8": true}
+		labels := &NodeLabels{}
+		n.addAMXLabels(labels, n.getSupportedCpuFeatures())
+		Expect(labels.ToMap()).ToNot(HaveKey(kubevirtv1.CPUFeatureLabel + "amx-int8"))
+
+		node := &v1.Node{}
+		n.setMigrationSensitiveFeaturesAnnotation(node, n.getSupportedCpuFeatures())
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.MigrationSensitiveFeaturesAnnotation, "amx-bf16,amx-tile"))
+	})
+})
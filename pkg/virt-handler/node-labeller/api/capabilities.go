@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/xml"
 	"fmt"
+	"sort"
 
 	hwutil "kubevirt.io/kubevirt/pkg/util/hardware"
 )
@@ -20,13 +21,54 @@ type Host struct {
 	UUID     string   `xml:"uuid"`
 	CPU      HostCPU  `xml:"cpu"`
 	Topology Topology `xml:"topology"`
+	Cache    *Cache   `xml:"cache"`
+}
+
+type Cache struct {
+	Banks []CacheBank `xml:"bank"`
+}
+
+type CacheBank struct {
+	ID    uint32 `xml:"id,attr"`
+	Level int    `xml:"level,attr"`
+	Type  string `xml:"type,attr"`
+	Size  uint64 `xml:"size,attr"`
+	Unit  string `xml:"unit,attr"`
+	CPUs  string `xml:"cpus,attr"`
+}
+
+// CacheLevel describes the size of a single cache instance of a cache level and type
+type CacheLevel struct {
+	Level int
+	// Type is either both, data or instruction
+	Type    string
+	SizeKiB uint64
+}
+
+var cacheUnitsInKiB = map[string]uint64{
+	"KiB": 1,
+	"MiB": 1024,
+	"GiB": 1024 * 1024,
 }
 
 type HostCPU struct {
-	Arch    string    `xml:"arch"`
-	Model   string    `xml:"model"`
-	Vendor  string    `xml:"vendor"`
-	Counter []Counter `xml:"counter"`
+	Arch      string           `xml:"arch"`
+	Model     string           `xml:"model"`
+	Vendor    string           `xml:"vendor"`
+	Microcode *Microcode       `xml:"microcode"`
+	Topology  *HostCPUTopology `xml:"topology"`
+	Counter   []Counter        `xml:"counter"`
+}
+
+type Microcode struct {
+	Version string `xml:"version,attr"`
+}
+
+type HostCPUTopology struct {
+	Sockets int `xml:"sockets,attr"`
+	Dies    int `xml:"dies,attr"`
+	Cores   int `xml:"cores,attr"`
+	Threads int `xml:"threads,attr"`
 }
 
 type Counter struct {
@@ -94,6 +136,89 @@ func (c *Capabilities) GetTSCCounter() (*Counter, error) {
 	return nil, nil
 }
 
+// SupportsNUMAMemoryBinding reports whether the host topology exposes NUMA cells
+// with memory that guest memory can be bound to. A single cell host qualifies too,
+// since binding to its only node is still valid.
+func (c *Capabilities) SupportsNUMAMemoryBinding() bool {
+	for _, cell := range c.Host.Topology.Cells.Cell {
+		if cell.Memory.Amount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNUMANodes returns the number of NUMA nodes of the host topology, or 0 if the topology is absent
+func (c *Capabilities) GetNUMANodes() int {
+	return len(c.Host.Topology.Cells.Cell)
+}
+
+// GetCPUTopology returns the number of sockets of the host, cores per socket and threads per core.
+// libvirt reports the sockets per NUMA node, so they are multiplied by the number of NUMA cells.
+// ok is false if the host does not expose a complete CPU topology.
+func (c *Capabilities) GetCPUTopology() (sockets, coresPerSocket, threadsPerCore int, ok bool) {
+	topology := c.Host.CPU.Topology
+	if topology == nil || topology.Sockets <= 0 || topology.Cores <= 0 || topology.Threads <= 0 {
+		return 0, 0, 0, false
+	}
+
+	sockets = topology.Sockets
+	if cells := len(c.Host.Topology.Cells.Cell); cells > 1 {
+		sockets *= cells
+	}
+	coresPerSocket = topology.Cores
+	if topology.Dies > 1 {
+		coresPerSocket *= topology.Dies
+	}
+	return sockets, coresPerSocket, topology.Threads, true
+}
+
+// GetCacheTopology returns the cache levels of the host sorted by level and type. Cache instances of
+// the same level and type may differ in size, then the largest one is returned. Banks with unknown
+// units are skipped.
+func (c *Capabilities) GetCacheTopology() []CacheLevel {
+	if c.Host.Cache == nil {
+		return nil
+	}
+
+	levels := make(map[CacheLevel]uint64)
+	for _, bank := range c.Host.Cache.Banks {
+		sizeKiB := bank.Size
+		if bank.Unit == "B" {
+			sizeKiB /= 1024
+		} else if factor, known := cacheUnitsInKiB[bank.Unit]; known {
+			sizeKiB *= factor
+		} else {
+			continue
+		}
+		key := CacheLevel{Level: bank.Level, Type: bank.Type}
+		if sizeKiB > levels[key] {
+			levels[key] = sizeKiB
+		}
+	}
+
+	cacheLevels := make([]CacheLevel, 0, len(levels))
+	for level, sizeKiB := range levels {
+		level.SizeKiB = sizeKiB
+		cacheLevels = append(cacheLevels, level)
+	}
+	sort.Slice(cacheLevels, func(i, j int) bool {
+		if cacheLevels[i].Level != cacheLevels[j].Level {
+			return cacheLevels[i].Level < cacheLevels[j].Level
+		}
+		return cacheLevels[i].Type < cacheLevels[j].Type
+	})
+	return cacheLevels
+}
+
+// GetMicrocodeVersion returns the microcode revision of the host cpu, if libvirt reports it
+func (c *Capabilities) GetMicrocodeVersion() (string, bool) {
+	if c.Host.CPU.Microcode == nil || c.Host.CPU.Microcode.Version == "" {
+		return "", false
+	}
+	return c.Host.CPU.Microcode.Version, true
+}
+
 func (b *yesnobool) UnmarshalXMLAttr(attr xml.Attr) error {
 	if attr.Value == "yes" {
 		*b = true
@@ -143,4 +143,81 @@ var _ = Describe("Capabilities", func() {
 		Expect(capabilities.Host.Topology.Cells.Cell).To(HaveLen(4))
 		Expect(capabilities.Host.Topology.Cells.Cell[0]).To(Equal(expectedCell))
 	})
+
+	DescribeTable("should detect NUMA memory binding support", func(file string, supported bool) {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		capabilities := &api.Capabilities{}
+		Expect(xml.NewDecoder(f).Decode(capabilities)).To(Succeed())
+		Expect(capabilities.SupportsNUMAMemoryBinding()).To(Equal(supported))
+	},
+		Entry("on a multi NUMA node host", "testdata/capabilities_with_numa.xml", true),
+		Entry("on a single NUMA node host", "testdata/capabilities.xml", true),
+		Entry("when the host topology is absent", "testdata/capabilities_no_topology.xml", false),
+	)
+
+	DescribeTable("should count the NUMA nodes of the host", func(file string, expectedNodes int) {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		capabilities := &api.Capabilities{}
+		Expect(xml.NewDecoder(f).Decode(capabilities)).To(Succeed())
+		Expect(capabilities.GetNUMANodes()).To(Equal(expectedNodes))
+	},
+		Entry("on a multi NUMA node host", "testdata/capabilities_with_numa.xml", 4),
+		Entry("on a single NUMA node host", "testdata/capabilities.xml", 1),
+		Entry("when the host topology is absent", "testdata/capabilities_no_topology.xml", 0),
+	)
+
+	DescribeTable("should read the cpu topology of the host", func(file string, expectedOk bool, expectedSockets, expectedCores, expectedThreads int) {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		capabilities := &api.Capabilities{}
+		Expect(xml.NewDecoder(f).Decode(capabilities)).To(Succeed())
+		sockets, coresPerSocket, threadsPerCore, ok := capabilities.GetCPUTopology()
+		Expect(ok).To(Equal(expectedOk))
+		Expect(sockets).To(Equal(expectedSockets))
+		Expect(coresPerSocket).To(Equal(expectedCores))
+		Expect(threadsPerCore).To(Equal(expectedThreads))
+	},
+		Entry("on a single NUMA node host", "testdata/capabilities.xml", true, 1, 4, 2),
+		Entry("on a multi NUMA node host", "testdata/capabilities_with_numa.xml", true, 4, 6, 1),
+		Entry("when the cpu topology is absent", "testdata/capabilities_no_topology.xml", false, 0, 0, 0),
+	)
+
+	DescribeTable("should read the cache topology of the host", func(file string, expectedLevels []api.CacheLevel) {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		capabilities := &api.Capabilities{}
+		Expect(xml.NewDecoder(f).Decode(capabilities)).To(Succeed())
+		Expect(capabilities.GetCacheTopology()).To(Equal(expectedLevels))
+	},
+		Entry("with a full cache topology", "testdata/capabilities_cache.xml", []api.CacheLevel{
+			{Level: 1, Type: "data", SizeKiB: 48},
+			{Level: 1, Type: "instruction", SizeKiB: 32},
+			{Level: 2, Type: "both", SizeKiB: 1280},
+			{Level: 3, Type: "both", SizeKiB: 12 * 1024},
+		}),
+		Entry("with an L3 cache only", "testdata/capabilities_with_numa.xml", []api.CacheLevel{
+			{Level: 3, Type: "both", SizeKiB: 16 * 1024},
+		}),
+		Entry("when the cache section is absent", "testdata/capabilities_no_topology.xml", nil),
+	)
+
+	DescribeTable("should read the microcode version of the host cpu", func(file string, expectedOk bool, expectedVersion string) {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		capabilities := &api.Capabilities{}
+		Expect(xml.NewDecoder(f).Decode(capabilities)).To(Succeed())
+		version, ok := capabilities.GetMicrocodeVersion()
+		Expect(ok).To(Equal(expectedOk))
+		Expect(version).To(Equal(expectedVersion))
+	},
+		Entry("when libvirt reports the microcode", "testdata/capabilities.xml", true, "214"),
+		Entry("when the microcode is absent", "testdata/capabilities_no_topology.xml", false, ""),
+	)
 })
@@ -0,0 +1,110 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+const (
+	defaultChangeHistoryLength = 10
+	// the history shares the 256KiB annotations limit of the node with everybody else,
+	// so keep it small
+	changeHistorySizeBudget = 8 * 1024
+)
+
+// labelChangeSummary describes a single labelling pass which changed the node labels
+type labelChangeSummary struct {
+	Timestamp    metav1.Time `json:"timestamp"`
+	AddedCount   int         `json:"addedCount"`
+	RemovedCount int         `json:"removedCount"`
+	Added        []string    `json:"added,omitempty"`
+	Removed      []string    `json:"removed,omitempty"`
+}
+
+// recordLabelChanges appends a summary of the label changes to the change history annotation
+// of the node. The history works as a ring buffer holding at most length entries, and the oldest
+// entries are dropped as well when the annotation would exceed budget bytes.
+func recordLabelChanges(node *v1.Node, added, removed []string, length, budget int) {
+	if length <= 0 || (len(added) == 0 && len(removed) == 0) {
+		return
+	}
+
+	history := []labelChangeSummary{}
+	if raw, exists := node.Annotations[kubevirtv1.LabellerChangeHistoryAnnotation]; exists {
+		if err := json.Unmarshal([]byte(raw), &history); err != nil {
+			log.Log.Reason(err).Warning("node-labeller discards the malformed label change history")
+			history = []labelChangeSummary{}
+		}
+	}
+
+	history = append(history, labelChangeSummary{
+		Timestamp:    metav1.Now(),
+		AddedCount:   len(added),
+		RemovedCount: len(removed),
+		Added:        added,
+		Removed:      removed,
+	})
+	if len(history) > length {
+		history = history[len(history)-length:]
+	}
+
+	raw, err := marshalChangeHistory(history, budget)
+	if err != nil {
+		log.Log.Reason(err).Warning("node-labeller could not record the label change history")
+		return
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.LabellerChangeHistoryAnnotation] = raw
+}
+
+// marshalChangeHistory drops the oldest entries until the history fits into budget bytes.
+// If even the latest entry alone is too large, only its counters are kept.
+func marshalChangeHistory(history []labelChangeSummary, budget int) (string, error) {
+	for {
+		raw, err := json.Marshal(history)
+		if err != nil {
+			return "", err
+		}
+		if len(raw) <= budget {
+			return string(raw), nil
+		}
+
+		if len(history) > 1 {
+			history = history[1:]
+			continue
+		}
+		if history[0].Added == nil && history[0].Removed == nil {
+			return "", fmt.Errorf("label change history does not fit into %d bytes", budget)
+		}
+		history[0].Added = nil
+		history[0].Removed = nil
+	}
+}
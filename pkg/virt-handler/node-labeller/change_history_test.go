@@ -0,0 +1,88 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	k8sv1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Label change history", func() {
+	var node *k8sv1.Node
+
+	getHistory := func() []labelChangeSummary {
+		history := []labelChangeSummary{}
+		Expect(json.Unmarshal([]byte(node.Annotations[kubevirtv1.LabellerChangeHistoryAnnotation]), &history)).To(Succeed())
+		return history
+	}
+
+	BeforeEach(func() {
+		node = &k8sv1.Node{}
+	})
+
+	It("should not record a pass without changes", func() {
+		recordLabelChanges(node, []string{}, []string{}, 3, changeHistorySizeBudget)
+		Expect(node.Annotations).ToNot(HaveKey(kubevirtv1.LabellerChangeHistoryAnnotation))
+	})
+
+	It("should cap the history at the configured number of entries", func() {
+		for i := 0; i < 5; i++ {
+			recordLabelChanges(node, []string{fmt.Sprintf("label-%d", i)}, nil, 3, changeHistorySizeBudget)
+		}
+
+		history := getHistory()
+		Expect(history).To(HaveLen(3))
+		Expect(history[0].Added).To(ConsistOf("label-2"))
+		Expect(history[2].Added).To(ConsistOf("label-4"))
+	})
+
+	It("should not exceed the size budget", func() {
+		const budget = 512
+		for i := 0; i < 10; i++ {
+			recordLabelChanges(node, []string{fmt.Sprintf("%s%d", kubevirtv1.CPUFeatureLabel, i)}, nil, 10, budget)
+		}
+
+		Expect(len(node.Annotations[kubevirtv1.LabellerChangeHistoryAnnotation])).To(BeNumerically("<=", budget))
+		history := getHistory()
+		Expect(len(history)).To(BeNumerically("<", 10))
+		Expect(history[len(history)-1].Added).To(ConsistOf(kubevirtv1.CPUFeatureLabel + "9"))
+	})
+
+	It("should keep only the counters of an entry larger than the size budget", func() {
+		added := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			added = append(added, fmt.Sprintf("%s%d", kubevirtv1.CPUFeatureLabel, i))
+		}
+		recordLabelChanges(node, added, []string{"removed"}, 10, 512)
+
+		history := getHistory()
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].AddedCount).To(Equal(100))
+		Expect(history[0].RemovedCount).To(Equal(1))
+		Expect(history[0].Added).To(BeEmpty())
+	})
+})
@@ -0,0 +1,83 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// cpuGovernorsPattern matches the scaling governor files of the host cpus, relative to the host root
+const cpuGovernorsPattern = "sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_governor"
+
+// mixedCPUGovernors is reported when the host cpus do not share the same scaling governor
+const mixedCPUGovernors = "mixed"
+
+// cpuGovernorReader returns the scaling governor of every host cpu
+type cpuGovernorReader func() ([]string, error)
+
+// readCPUGovernors reads the scaling governors of the host cpus, hosts without cpufreq support,
+// e.g. virtual machines, have none
+func readCPUGovernors(hostFS fs.FS) ([]string, error) {
+	if hostFS == nil {
+		return nil, fmt.Errorf("host filesystem is not available")
+	}
+	governorFiles, err := fs.Glob(hostFS, cpuGovernorsPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	governors := make([]string, 0, len(governorFiles))
+	for _, governorFile := range governorFiles {
+		content, err := fs.ReadFile(hostFS, governorFile)
+		if err != nil {
+			return nil, err
+		}
+		governors = append(governors, strings.TrimSpace(string(content)))
+	}
+	return governors, nil
+}
+
+// cpuGovernor returns the scaling governor shared by all cpus, or mixed if they differ
+func cpuGovernor(governors []string) string {
+	for _, governor := range governors[1:] {
+		if governor != governors[0] {
+			return mixedCPUGovernors
+		}
+	}
+	return governors[0]
+}
+
+// addCPUGovernorLabel labels the scaling governor of the host cpus, e.g. performance. The governor
+// can be changed at runtime, hence it is read on every reconcile.
+func (n *NodeLabeller) addCPUGovernorLabel(labels *NodeLabels) {
+	if n.cpuGovernorReader == nil {
+		return
+	}
+	governors, err := n.cpuGovernorReader()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not read the cpu scaling governors of the host")
+		return
+	}
+	if len(governors) == 0 {
+		return
+	}
+	labels.CPUGovernor = cpuGovernor(governors)
+}
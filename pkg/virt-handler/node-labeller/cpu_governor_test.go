@@ -0,0 +1,81 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"strings"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("CPU governor", func() {
+
+	It("should read the scaling governor of every cpu", func() {
+		hostFS := fstest.MapFS{
+			"sys/devices/system/cpu/cpu0/cpufreq/scaling_governor":    &fstest.MapFile{Data: []byte("performance\n")},
+			"sys/devices/system/cpu/cpu1/cpufreq/scaling_governor":    &fstest.MapFile{Data: []byte("powersave\n")},
+			"sys/devices/system/cpu/cpufreq/policy0/scaling_governor": &fstest.MapFile{Data: []byte("schedutil\n")},
+		}
+		governors, err := readCPUGovernors(hostFS)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(governors).To(ConsistOf("performance", "powersave"))
+	})
+
+	It("should read no governors without cpufreq support", func() {
+		governors, err := readCPUGovernors(fstest.MapFS{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(governors).To(BeEmpty())
+	})
+
+	DescribeTable("should label the governor of the governor reader", func(reader cpuGovernorReader, expectedLabels map[string]string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), cpuGovernorReader: reader}
+		labels := &NodeLabels{}
+		n.addCPUGovernorLabel(labels)
+
+		governorLabels := map[string]string{}
+		for key, value := range labels.ToMap() {
+			if strings.HasPrefix(key, kubevirtv1.CPUGovernorLabel) {
+				governorLabels[key] = value
+			}
+		}
+		Expect(governorLabels).To(Equal(expectedLabels))
+	},
+		Entry("with a uniform governor",
+			cpuGovernorReader(func() ([]string, error) { return []string{"performance", "performance"}, nil }),
+			map[string]string{kubevirtv1.CPUGovernorLabel + "performance": "true"}),
+		Entry("with a single cpu",
+			cpuGovernorReader(func() ([]string, error) { return []string{"powersave"}, nil }),
+			map[string]string{kubevirtv1.CPUGovernorLabel + "powersave": "true"}),
+		Entry("with mixed governors",
+			cpuGovernorReader(func() ([]string, error) { return []string{"performance", "powersave", "performance"}, nil }),
+			map[string]string{kubevirtv1.CPUGovernorLabel + "mixed": "true"}),
+		Entry("without cpufreq support",
+			cpuGovernorReader(func() ([]string, error) { return []string{}, nil }),
+			map[string]string{}),
+		Entry("when the reader fails",
+			cpuGovernorReader(func() ([]string, error) { return nil, fmt.Errorf("sysfs is not available") }),
+			map[string]string{}),
+	)
+})
@@ -39,6 +39,7 @@ const (
 	isSupported            string = "yes"
 	isUnusable             string = "no"
 	isRequired             string = "require"
+	isNotMigratable        string = "no"
 	nodeLabellerVolumePath        = "/var/lib/kubevirt-node-labeller/"
 
 	supportedFeaturesXml = "supported_features.xml"
@@ -52,21 +53,8 @@ func (n *NodeLabeller) getMinCpuFeature() cpuFeatures {
 	return n.cpuInfo.usableModels[minCPUModel]
 }
 
-func (n *NodeLabeller) getSupportedCpuModels(obsoleteCPUsx86 map[string]bool) []string {
-	supportedCPUModels := make([]string, 0)
-
-	if obsoleteCPUsx86 == nil {
-		obsoleteCPUsx86 = util.DefaultObsoleteCPUModels
-	}
-
-	for _, model := range n.hostCapabilities.items {
-		if _, ok := obsoleteCPUsx86[model]; ok {
-			continue
-		}
-		supportedCPUModels = append(supportedCPUModels, model)
-	}
-
-	return supportedCPUModels
+func (n *NodeLabeller) getSupportedCpuModels(policy CPUModelPolicy) []string {
+	return policy.Filter(n.hostCapabilities.Items(), n.logger)
 }
 
 func (n *NodeLabeller) getSupportedCpuFeatures() cpuFeatures {
@@ -91,6 +79,7 @@ func (n *NodeLabeller) loadDomCapabilities() error {
 	}
 
 	usableModels := make([]string, 0)
+	skippedModels := 0
 	for _, mode := range hostDomCapabilities.CPU.Mode {
 		if mode.Name == v1.CPUModeHostModel {
 			if virtconfig.IsARM64(runtime.GOARCH) {
@@ -108,7 +97,10 @@ func (n *NodeLabeller) loadDomCapabilities() error {
 			}
 
 			hostCpuModel := mode.Model[0]
-			n.hostCPUModel.Name = hostCpuModel.Name
+			if strings.TrimSpace(hostCpuModel.Name) == "" {
+				log.Log.Warning("host model mode contains a model with an empty name")
+			}
+			n.hostCPUModel.Name = strings.TrimSpace(hostCpuModel.Name)
 			n.hostCPUModel.fallback = hostCpuModel.Fallback
 
 			for _, feature := range mode.Feature {
@@ -119,19 +111,52 @@ func (n *NodeLabeller) loadDomCapabilities() error {
 		}
 
 		for _, model := range mode.Model {
-			if model.Usable == isUnusable || model.Usable == "" {
+			if model.Usable == "" {
+				continue
+			}
+			if strings.TrimSpace(model.Name) == "" {
+				skippedModels++
+				continue
+			}
+			if model.Usable == isUnusable {
 				continue
 			}
 			usableModels = append(usableModels, model.Name)
 		}
 	}
+	if skippedModels > 0 {
+		log.Log.Warningf("skipped %d cpu models with an empty name", skippedModels)
+	}
 
-	n.hostCapabilities.items = usableModels
+	n.hostCapabilities = newSupportedFeatures(usableModels)
+	n.cpuInfo.modelUsability = hostDomCapabilities.ModelUsability()
+	n.cpuInfo.machineTypes = hostDomCapabilities.Machines
+	n.kvmAvailable = hostDomCapabilities.Domain == "kvm"
+	n.maxVCPUs = hostDomCapabilities.VCPU.GetMax()
+	n.cpuHotplugSupported = hostDomCapabilities.SupportsCPUHotplug()
 	n.SEV = hostDomCapabilities.SEV
+	n.gicVersions = hostDomCapabilities.GIC.Versions()
+	n.diskAIOModes = hostDomCapabilities.Devices.Disk.AIOModes()
+	n.watchdogActions = hostDomCapabilities.Devices.Watchdog.Actions()
+	n.virtioIOMMUSupported = hostDomCapabilities.Devices.IOMMU.SupportsVirtIO()
+	n.memoryHotUnplug = hostDomCapabilities.Devices.Memory.SupportsHotUnplug()
+	n.virglSupported = hostDomCapabilities.Devices.SupportsVirgl()
+	n.vendorIDSpoofSupported = hostDomCapabilities.Hyperv.SupportsVendorID()
 
 	return nil
 }
 
+// reloadModelUsability reads the usability of the cpu models from the host dom capabilities again, so that
+// models the host lost since the previous reconcile, e.g. after a microcode update, are noticed
+func (n *NodeLabeller) reloadModelUsability() error {
+	hostDomCapabilities, err := n.getDomCapabilities()
+	if err != nil {
+		return err
+	}
+	n.cpuInfo.modelUsability = hostDomCapabilities.ModelUsability()
+	return nil
+}
+
 // loadHostSupportedFeatures loads supported features
 func (n *NodeLabeller) loadHostSupportedFeatures() error {
 	featuresFile := filepath.Join(n.volumePath, supportedFeaturesXml)
@@ -143,12 +168,21 @@ func (n *NodeLabeller) loadHostSupportedFeatures() error {
 	}
 
 	usableFeatures := make([]string, 0)
+	requiredFeatures := Features{}
+	n.cpuInfo.hostFeatures = make(cpuFeatures)
 	for _, f := range hostFeatures.Feature {
 		if f.Policy != util.RequirePolicy {
 			continue
 		}
 
 		usableFeatures = append(usableFeatures, f.Name)
+		requiredFeatures.Features = append(requiredFeatures.Features, f)
+		n.cpuInfo.hostFeatures[f.Name] = true
+	}
+
+	n.cpuInfo.migratableHostFeatures = make(cpuFeatures)
+	for _, f := range requiredFeatures.MigratableFeatures() {
+		n.cpuInfo.migratableHostFeatures[f.Name] = true
 	}
 
 	n.supportedFeatures = usableFeatures
@@ -23,6 +23,7 @@ package nodelabeller
 
 import (
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/golang/mock/gomock"
@@ -30,6 +31,7 @@ import (
 	. "github.com/onsi/gomega"
 	k8sv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/client-go/kubecli"
@@ -109,7 +111,7 @@ var _ = Describe("Node-labeller config", func() {
 		err = nlController.loadHostCapabilities()
 		Expect(err).ToNot(HaveOccurred())
 
-		cpuModels := nlController.getSupportedCpuModels(nlController.clusterConfig.GetObsoleteCPUModels())
+		cpuModels := nlController.getSupportedCpuModels(nlController.getCPUModelPolicy())
 		cpuFeatures := nlController.getSupportedCpuFeatures()
 
 		Expect(cpuModels).To(HaveLen(5), "number of models must match")
@@ -132,7 +134,7 @@ var _ = Describe("Node-labeller config", func() {
 
 		Expect(nlController.loadHostSupportedFeatures()).To(Succeed())
 
-		cpuModels := nlController.getSupportedCpuModels(nlController.clusterConfig.GetObsoleteCPUModels())
+		cpuModels := nlController.getSupportedCpuModels(nlController.getCPUModelPolicy())
 		cpuFeatures := nlController.getSupportedCpuFeatures()
 
 		Expect(cpuModels).To(BeEmpty(), "no CPU models are expected to be supported")
@@ -211,6 +213,125 @@ var _ = Describe("Node-labeller config", func() {
 		)
 	})
 
+	Context("return correct disk aio modes", func() {
+		It("when the disk aio enum is reported", func() {
+			nlController.domCapabilitiesFileName = "virsh_domcapabilities.xml"
+			Expect(nlController.loadDomCapabilities()).To(Succeed())
+			Expect(nlController.diskAIOModes).To(ConsistOf("native", "threads", "io_uring"))
+		})
+
+		It("when the disk aio enum is absent", func() {
+			nlController.domCapabilitiesFileName = "domcapabilities_nosev.xml"
+			Expect(nlController.loadDomCapabilities()).To(Succeed())
+			Expect(nlController.diskAIOModes).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("return correct maximum number of vCPUs", func(domCapabilitiesFileName string, maxVCPUs int) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.maxVCPUs).To(Equal(maxVCPUs))
+
+		Expect(nlController.loadHostCapabilities()).To(Succeed())
+		labels := nlController.prepareLabels(&k8sv1.Node{}, []string{}, cpuFeatures{}, hostCPUModel{}, map[string]bool{}, nil)
+		if maxVCPUs > 0 {
+			Expect(labels).To(HaveKeyWithValue(kubevirtv1.MaxVCPULabel, strconv.Itoa(maxVCPUs)))
+		} else {
+			Expect(labels).ToNot(HaveKey(kubevirtv1.MaxVCPULabel))
+		}
+	},
+		Entry("when the maximum is reported", "domcapabilities_max_vcpu.xml", 4096),
+		Entry("when the maximum is absent", "virsh_domcapabilities.xml", 0),
+	)
+
+	DescribeTable("return correct cpu hotplug support", func(domCapabilitiesFileName string, supported bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.cpuHotplugSupported).To(Equal(supported))
+
+		Expect(nlController.loadHostCapabilities()).To(Succeed())
+		labels := nlController.prepareLabels(&k8sv1.Node{}, []string{}, cpuFeatures{}, hostCPUModel{}, map[string]bool{}, nil)
+		Expect(labels).To(HaveKeyWithValue(kubevirtv1.CPUHotplugLabel, strconv.FormatBool(supported)))
+	},
+		Entry("on a kvm host advertising multiple vCPUs", "domcapabilities_cpu_hotplug.xml", true),
+		Entry("without a domain type", "virsh_domcapabilities.xml", false),
+	)
+
+	It("should skip cpu models with an empty name", func() {
+		nlController.domCapabilitiesFileName = "domcapabilities_empty_model.xml"
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.hostCapabilities.Items()).To(ConsistOf("Penryn", "Skylake-Client-IBRS"))
+		Expect(nlController.hostCPUModel.Name).To(BeEmpty())
+		Expect(nlController.loadHostCapabilities()).To(Succeed())
+
+		labels := nlController.prepareLabels(&k8sv1.Node{}, nlController.hostCapabilities.Items(), cpuFeatures{}, nlController.GetHostCpuModel(), map[string]bool{}, nil)
+		for key := range labels {
+			Expect(validation.IsQualifiedName(key)).To(BeEmpty(), "label key %q should be valid", key)
+		}
+	})
+
+	DescribeTable("return correct kvm availability", func(domCapabilitiesFileName string, available bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.kvmAvailable).To(Equal(available))
+	},
+		Entry("with the kvm domain type", "domcapabilities_sev.xml", true),
+		Entry("without a domain type", "virsh_domcapabilities.xml", false),
+	)
+
+	DescribeTable("return correct watchdog actions", func(domCapabilitiesFileName string, actions []string) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.watchdogActions).To(Equal(actions))
+	},
+		Entry("when the watchdog actions are reported", "domcapabilities_watchdog.xml", []string{"reset", "shutdown", "poweroff", "pause", "none", "dump", "inject-nmi"}),
+		Entry("when the watchdog device is unsupported", "domcapabilities_watchdog_unsupported.xml", nil),
+		Entry("when the watchdog device is absent", "domcapabilities_nosev.xml", nil),
+	)
+
+	DescribeTable("return correct vendor_id spoofing support", func(domCapabilitiesFileName string, supported bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.vendorIDSpoofSupported).To(Equal(supported))
+
+		Expect(nlController.loadHostCapabilities()).To(Succeed())
+		labels := nlController.prepareLabels(&k8sv1.Node{}, []string{}, cpuFeatures{}, hostCPUModel{}, map[string]bool{}, nil)
+		Expect(labels).To(HaveKeyWithValue(kubevirtv1.CPUVendorIDSpoofLabel, strconv.FormatBool(supported)))
+	},
+		Entry("with the vendor_id enlightenment", "domcapabilities_hyperv.xml", true),
+		Entry("when the hyperv features are absent", "domcapabilities_nosev.xml", false),
+	)
+
+	DescribeTable("return correct memory hot-unplug support", func(domCapabilitiesFileName string, supported bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.memoryHotUnplug).To(Equal(supported))
+	},
+		Entry("with the virtio-mem model", "domcapabilities_memory_hotplug.xml", true),
+		Entry("with the dimm model only", "domcapabilities_memory_dimm.xml", false),
+		Entry("when the memory device is absent", "domcapabilities_nosev.xml", false),
+	)
+
+	DescribeTable("return correct virgl support", func(domCapabilitiesFileName string, supported bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.virglSupported).To(Equal(supported))
+	},
+		Entry("with virtio video and an OpenGL display", "domcapabilities_virgl.xml", true),
+		Entry("on a build without virgl", "domcapabilities_sev.xml", false),
+		Entry("when the video device is absent", "domcapabilities_iommu_x86.xml", false),
+	)
+
+	DescribeTable("return correct virtio-iommu support", func(domCapabilitiesFileName string, supported bool) {
+		nlController.domCapabilitiesFileName = domCapabilitiesFileName
+		Expect(nlController.loadDomCapabilities()).To(Succeed())
+		Expect(nlController.virtioIOMMUSupported).To(Equal(supported))
+	},
+		Entry("on a x86 host", "domcapabilities_iommu_x86.xml", true),
+		Entry("on an ARM host", "domcapabilities_iommu_arm64.xml", true),
+		Entry("when the iommu device is absent", "domcapabilities_nosev.xml", false),
+	)
+
 	It("Make sure proper labels are removed on removeLabellerLabels()", func() {
 		node := &k8sv1.Node{
 			ObjectMeta: metav1.ObjectMeta{
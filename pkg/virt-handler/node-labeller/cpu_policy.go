@@ -0,0 +1,213 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"sort"
+
+	"kubevirt.io/client-go/log"
+
+	util "kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/util"
+)
+
+// intelGenerations are the Intel cpu model generations shared by the client and the server models
+var intelGenerations = [][]string{
+	{"486"},
+	{"pentium", "pentiumpro"},
+	{"pentium2"},
+	{"pentium3"},
+	{"coreduo", "n270"},
+	{"core2duo"},
+	{"Conroe"},
+	{"Penryn"},
+	{"Nehalem", "Nehalem-IBRS"},
+	{"Westmere", "Westmere-IBRS"},
+	{"SandyBridge", "SandyBridge-IBRS"},
+	{"IvyBridge", "IvyBridge-IBRS"},
+	{"Haswell", "Haswell-IBRS", "Haswell-noTSX", "Haswell-noTSX-IBRS"},
+	{"Broadwell", "Broadwell-IBRS", "Broadwell-noTSX", "Broadwell-noTSX-IBRS"},
+}
+
+// intelServerGenerations are the Intel server cpu model generations shared by Cooperlake and the Icelake servers
+var intelServerGenerations = lineage(intelGenerations,
+	[]string{"Skylake-Server", "Skylake-Server-IBRS", "Skylake-Server-noTSX-IBRS"},
+	[]string{"Cascadelake-Server", "Cascadelake-Server-noTSX"},
+)
+
+// opteronGenerations are the AMD cpu model generations shared by the later Opteron and the EPYC models
+var opteronGenerations = [][]string{
+	{"Opteron_G1"},
+	{"Opteron_G2"},
+	{"Opteron_G3"},
+}
+
+// knownCPUModelOrderings lists lineages of cpu model generations from the oldest to the newest. As in libvirt's
+// cpu_map, every generation of a lineage supports the features of the previous ones. Where newer models are no
+// supersets of each other, e.g. Cooperlake and Icelake-Client or Skylake-Client and Skylake-Server, the lineages
+// branch and share their older generations. Variants of the same generation (e.g. -IBRS, -noTSX) share the
+// generation of their base model.
+var knownCPUModelOrderings = [][][]string{
+	lineage(intelGenerations,
+		[]string{"Skylake-Client", "Skylake-Client-IBRS", "Skylake-Client-noTSX-IBRS"},
+		[]string{"Icelake-Client", "Icelake-Client-noTSX"},
+	),
+	lineage(intelServerGenerations,
+		[]string{"Cooperlake"},
+	),
+	lineage(intelServerGenerations,
+		[]string{"Icelake-Server", "Icelake-Server-noTSX"},
+		[]string{"SapphireRapids"},
+	),
+	{
+		{"athlon"},
+		{"phenom"},
+	},
+	lineage(opteronGenerations,
+		[]string{"Opteron_G4"},
+		[]string{"Opteron_G5"},
+	),
+	lineage(opteronGenerations,
+		[]string{"EPYC", "EPYC-IBPB", "Dhyana"},
+		[]string{"EPYC-Rome"},
+		[]string{"EPYC-Milan"},
+		[]string{"EPYC-Genoa"},
+	),
+}
+
+// lineage returns a copy of the generations followed by the newer generations
+func lineage(generations [][]string, newerGenerations ...[]string) [][]string {
+	return append(append([][]string{}, generations...), newerGenerations...)
+}
+
+type cpuModelGeneration struct {
+	ordering   int
+	generation int
+}
+
+// knownCPUModelGenerations maps the known cpu models to their generation in every lineage they are part of
+var knownCPUModelGenerations = func() map[string][]cpuModelGeneration {
+	generations := make(map[string][]cpuModelGeneration)
+	for ordering, generationList := range knownCPUModelOrderings {
+		for generation, models := range generationList {
+			for _, model := range models {
+				generations[model] = append(generations[model], cpuModelGeneration{ordering: ordering, generation: generation})
+			}
+		}
+	}
+	return generations
+}()
+
+// isOlderCPUModel checks if the cpu model precedes the other cpu model in one of the known lineages,
+// i.e. if the other model supports every feature of the model
+func isOlderCPUModel(model, other string) bool {
+	for _, generation := range knownCPUModelGenerations[model] {
+		for _, otherGeneration := range knownCPUModelGenerations[other] {
+			if generation.ordering == otherGeneration.ordering && generation.generation < otherGeneration.generation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CPUModelPolicy is the cluster wide policy deciding which usable cpu models are labelled
+type CPUModelPolicy struct {
+	// MinCPUModel is the oldest cpu model to label, older models of its known ordering are skipped
+	MinCPUModel string
+	// ObsoleteCPUModels are never labelled
+	ObsoleteCPUModels map[string]bool
+}
+
+// getCPUModelPolicy returns the cpu model policy of the cluster configuration, falling back to the defaults
+func (n *NodeLabeller) getCPUModelPolicy() CPUModelPolicy {
+	policy := CPUModelPolicy{
+		MinCPUModel:       n.clusterConfig.GetMinCPUModel(),
+		ObsoleteCPUModels: n.clusterConfig.GetObsoleteCPUModels(),
+	}
+	if policy.MinCPUModel == "" {
+		policy.MinCPUModel = util.DefaultMinCPUModel
+	}
+	if policy.ObsoleteCPUModels == nil {
+		policy.ObsoleteCPUModels = util.DefaultObsoleteCPUModels
+	}
+	return policy
+}
+
+// Filter removes the obsolete models and the models older than the minimal cpu model.
+// Models outside of the known orderings or of the lineages of the minimal model can't be compared, so they are kept.
+func (p CPUModelPolicy) Filter(models []string, logger *log.FilteredLogger) []string {
+	filtered := make([]string, 0, len(models))
+	for _, model := range models {
+		if p.ObsoleteCPUModels[model] {
+			continue
+		}
+
+		if _, known := knownCPUModelGenerations[model]; !known {
+			logger.V(4).Infof("cpu model %s has no known ordering, keeping it regardless of the minimal cpu model %s", model, p.MinCPUModel)
+			filtered = append(filtered, model)
+			continue
+		}
+		if isOlderCPUModel(model, p.MinCPUModel) {
+			continue
+		}
+		filtered = append(filtered, model)
+	}
+	return filtered
+}
+
+// UnmatchedObsoleteModels returns the sorted obsolete cpu models which match none of the known cpu models,
+// e.g. because of a typo in the configuration, since such entries silently have no effect
+func (p CPUModelPolicy) UnmatchedObsoleteModels(knownModels map[string]bool) []string {
+	unmatched := []string{}
+	for model, obsolete := range p.ObsoleteCPUModels {
+		if obsolete && !knownModels[model] {
+			unmatched = append(unmatched, model)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// warnUnmatchedObsoleteModels logs the obsolete cpu models of the cluster configuration which match none of the
+// cpu models libvirt reports for the host, and returns them. Only obsolete models the user configured are validated,
+// the default obsolete models are not expected to match the models of every host, e.g. of an arm64 one.
+func (n *NodeLabeller) warnUnmatchedObsoleteModels() []string {
+	obsoleteCPUModels := n.clusterConfig.GetObsoleteCPUModels()
+	if len(obsoleteCPUModels) == 0 {
+		return nil
+	}
+
+	knownModels := make(map[string]bool, len(n.cpuInfo.modelUsability)+len(n.cpuInfo.usableModels)+1)
+	for model := range n.cpuInfo.modelUsability {
+		knownModels[model] = true
+	}
+	for model := range n.cpuInfo.usableModels {
+		knownModels[model] = true
+	}
+	if n.hostCPUModel.Name != "" {
+		knownModels[n.hostCPUModel.Name] = true
+	}
+
+	unmatched := CPUModelPolicy{ObsoleteCPUModels: obsoleteCPUModels}.UnmatchedObsoleteModels(knownModels)
+	if len(unmatched) > 0 {
+		n.logger.Warningf("obsolete cpu models %v match none of the cpu models known to the host, they have no effect", unmatched)
+	}
+	return unmatched
+}
@@ -0,0 +1,115 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+
+	"kubevirt.io/kubevirt/pkg/testutils"
+)
+
+var _ = Describe("CPU model policy", func() {
+	models := []string{"Conroe", "Penryn", "Nehalem", "IvyBridge", "Haswell-noTSX", "Skylake-Client-IBRS", "Opteron_G2", "EPYC", "cortex-a57"}
+
+	DescribeTable("should filter the usable cpu models", func(policy CPUModelPolicy, expected []string) {
+		Expect(policy.Filter(models, log.DefaultLogger())).To(Equal(expected))
+	},
+		Entry("with a minimal model in the middle of the ordering",
+			CPUModelPolicy{MinCPUModel: "Nehalem"},
+			[]string{"Nehalem", "IvyBridge", "Haswell-noTSX", "Skylake-Client-IBRS", "Opteron_G2", "EPYC", "cortex-a57"},
+		),
+		Entry("with a minimal model variant sharing the generation of its base model",
+			CPUModelPolicy{MinCPUModel: "Haswell-IBRS"},
+			[]string{"Haswell-noTSX", "Skylake-Client-IBRS", "Opteron_G2", "EPYC", "cortex-a57"},
+		),
+		Entry("with a minimal model of another vendor",
+			CPUModelPolicy{MinCPUModel: "EPYC"},
+			[]string{"Conroe", "Penryn", "Nehalem", "IvyBridge", "Haswell-noTSX", "Skylake-Client-IBRS", "EPYC", "cortex-a57"},
+		),
+		Entry("with obsolete models",
+			CPUModelPolicy{MinCPUModel: "Nehalem", ObsoleteCPUModels: map[string]bool{"IvyBridge": true, "Opteron_G2": true}},
+			[]string{"Nehalem", "Haswell-noTSX", "Skylake-Client-IBRS", "EPYC", "cortex-a57"},
+		),
+		Entry("with a minimal model outside of the lineage of a model",
+			CPUModelPolicy{MinCPUModel: "Skylake-Server"},
+			[]string{"Skylake-Client-IBRS", "Opteron_G2", "EPYC", "cortex-a57"},
+		),
+		Entry("with an unknown minimal model",
+			CPUModelPolicy{MinCPUModel: "unknown"},
+			models,
+		),
+	)
+
+	DescribeTable("should order the cpu models by their feature supersets", func(model, other string, older bool) {
+		Expect(isOlderCPUModel(model, other)).To(Equal(older))
+	},
+		Entry("Penryn before Skylake-Client", "Penryn", "Skylake-Client", true),
+		Entry("Broadwell before Skylake-Server", "Broadwell-noTSX", "Skylake-Server", true),
+		Entry("Skylake-Client before Icelake-Client", "Skylake-Client-IBRS", "Icelake-Client", true),
+		Entry("Skylake-Client not before Skylake-Server", "Skylake-Client", "Skylake-Server", false),
+		Entry("Skylake-Server not before Skylake-Client", "Skylake-Server", "Skylake-Client", false),
+		Entry("Cascadelake-Server before Cooperlake", "Cascadelake-Server", "Cooperlake", true),
+		Entry("Cascadelake-Server before Icelake-Server", "Cascadelake-Server-noTSX", "Icelake-Server", true),
+		Entry("Icelake-Server before SapphireRapids", "Icelake-Server", "SapphireRapids", true),
+		Entry("Cooperlake not before Icelake-Client", "Cooperlake", "Icelake-Client", false),
+		Entry("Icelake-Client not before Cooperlake", "Icelake-Client", "Cooperlake", false),
+		Entry("Cooperlake not before Icelake-Server", "Cooperlake", "Icelake-Server", false),
+		Entry("Opteron_G3 before EPYC", "Opteron_G3", "EPYC", true),
+		Entry("Opteron_G5 not before EPYC", "Opteron_G5", "EPYC", false),
+		Entry("EPYC-Rome before EPYC-Milan", "EPYC-Rome", "EPYC-Milan", true),
+		Entry("variants of the same generation", "Haswell-noTSX", "Haswell-IBRS", false),
+		Entry("models of different vendors", "Penryn", "EPYC", false),
+		Entry("an unknown model", "unknown", "EPYC", false),
+	)
+
+	It("should report the obsolete models matching no known model", func() {
+		policy := CPUModelPolicy{ObsoleteCPUModels: map[string]bool{"Penyrn": true, "Conroe": true, "Opteron_G1": false}}
+		known := map[string]bool{"Conroe": true, "Penryn": true}
+		Expect(policy.UnmatchedObsoleteModels(known)).To(Equal([]string{"Penyrn"}))
+	})
+
+	It("should warn about a misspelled obsolete model of the configuration", func() {
+		config, _, _ := testutils.NewFakeClusterConfigUsingKVConfig(&kubevirtv1.KubeVirtConfiguration{
+			ObsoleteCPUModels: map[string]bool{"Penyrn": true, "Conroe": true, "Skylake-Client-IBRS": true},
+		})
+		n := &NodeLabeller{
+			logger:        log.DefaultLogger(),
+			clusterConfig: config,
+			cpuInfo:       cpuInfo{modelUsability: map[string]bool{"Conroe": true, "Penryn": false}},
+			hostCPUModel:  hostCPUModel{Name: "Skylake-Client-IBRS"},
+		}
+		Expect(n.warnUnmatchedObsoleteModels()).To(Equal([]string{"Penyrn"}))
+	})
+
+	It("should not warn about the default obsolete models if the configuration sets none", func() {
+		config, _, _ := testutils.NewFakeClusterConfigUsingKVConfig(&kubevirtv1.KubeVirtConfiguration{})
+		n := &NodeLabeller{
+			logger:        log.DefaultLogger(),
+			clusterConfig: config,
+			cpuInfo:       cpuInfo{modelUsability: map[string]bool{"cortex-a57": true}},
+			hostCPUModel:  hostCPUModel{Name: "host-passthrough"},
+		}
+		Expect(n.warnUnmatchedObsoleteModels()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,121 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// cpuVulnerabilitiesDir holds a status file per cpu vulnerability known to the kernel, relative to the host root
+const cpuVulnerabilitiesDir = "sys/devices/system/cpu/vulnerabilities"
+
+// cpuVulnerabilityReader returns the mitigation status of the host cpu per vulnerability, e.g. mds
+type cpuVulnerabilityReader func() (map[string]string, error)
+
+// readCPUVulnerabilities reads the mitigation status of every cpu vulnerability the kernel knows about,
+// e.g. "Mitigation: PTE Inversion" for l1tf. Kernels predating the reporting have no status at all.
+func readCPUVulnerabilities(hostFS fs.FS) (map[string]string, error) {
+	if hostFS == nil {
+		return nil, fmt.Errorf("host filesystem is not available")
+	}
+	entries, err := fs.ReadDir(hostFS, cpuVulnerabilitiesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := fs.ReadFile(hostFS, path.Join(cpuVulnerabilitiesDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		vulnerabilities[entry.Name()] = strings.TrimSpace(string(content))
+	}
+	return vulnerabilities, nil
+}
+
+// isMitigated checks if the status reports the cpu as not affected or the vulnerability as fully mitigated.
+// A mitigation which leaves a part exposed, e.g. "Mitigation: Clear CPU buffers; SMT vulnerable", does not count.
+func isMitigated(status string) bool {
+	if status == "Not affected" {
+		return true
+	}
+	return strings.HasPrefix(status, "Mitigation") && !strings.Contains(strings.ToLower(status), "vulnerable")
+}
+
+// loadCPUVulnerabilities records the mitigation status of the host cpu. The status only changes with
+// a reboot of the host, hence it is read once. A failing read is logged without blocking the labelling.
+func (n *NodeLabeller) loadCPUVulnerabilities() {
+	n.cpuVulnerabilities = nil
+	if n.cpuVulnerabilityReader == nil {
+		return
+	}
+
+	vulnerabilities, err := n.cpuVulnerabilityReader()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not read the cpu vulnerabilities of the host")
+		return
+	}
+	n.cpuVulnerabilities = vulnerabilities
+}
+
+// addCPUVulnerabilityLabel labels whether all the cpu vulnerabilities known to the kernel are mitigated,
+// the label is omitted when the kernel does not report any
+func (n *NodeLabeller) addCPUVulnerabilityLabel(labels *NodeLabels) {
+	if len(n.cpuVulnerabilities) == 0 {
+		return
+	}
+	mitigated := true
+	for _, status := range n.cpuVulnerabilities {
+		mitigated = mitigated && isMitigated(status)
+	}
+	labels.CPUVulnMitigated = &mitigated
+}
+
+// setCPUVulnerabilityAnnotations records the mitigation status per cpu vulnerability on the node,
+// dropping the annotations of vulnerabilities which are no longer reported
+func (n *NodeLabeller) setCPUVulnerabilityAnnotations(node *v1.Node) {
+	for annotation := range node.Annotations {
+		if strings.HasPrefix(annotation, kubevirtv1.CPUVulnerabilityAnnotation) {
+			delete(node.Annotations, annotation)
+		}
+	}
+	if len(n.cpuVulnerabilities) == 0 {
+		return
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	for vulnerability, status := range n.cpuVulnerabilities {
+		node.Annotations[kubevirtv1.CPUVulnerabilityAnnotation+vulnerability] = status
+	}
+}
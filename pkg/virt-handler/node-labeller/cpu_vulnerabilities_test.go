@@ -0,0 +1,119 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("CPU vulnerabilities", func() {
+
+	mitigatedHost := map[string]string{
+		"l1tf":            "Mitigation: PTE Inversion; VMX: conditional cache flushes, SMT disabled",
+		"mds":             "Mitigation: Clear CPU buffers; SMT disabled",
+		"meltdown":        "Not affected",
+		"spectre_v2":      "Mitigation: Enhanced IBRS",
+		"srbds":           "Not affected",
+		"tsx_async_abort": "Not affected",
+	}
+	vulnerableHost := map[string]string{
+		"l1tf":     "Mitigation: PTE Inversion; VMX: conditional cache flushes, SMT vulnerable",
+		"mds":      "Vulnerable: Clear CPU buffers attempted, no microcode; SMT vulnerable",
+		"meltdown": "Mitigation: PTI",
+	}
+
+	newHostFS := func(vulnerabilities map[string]string) fstest.MapFS {
+		hostFS := fstest.MapFS{}
+		for vulnerability, status := range vulnerabilities {
+			hostFS[cpuVulnerabilitiesDir+"/"+vulnerability] = &fstest.MapFile{Data: []byte(status + "\n")}
+		}
+		return hostFS
+	}
+
+	It("should read the status of every cpu vulnerability", func() {
+		vulnerabilities, err := readCPUVulnerabilities(newHostFS(vulnerableHost))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vulnerabilities).To(Equal(vulnerableHost))
+	})
+
+	It("should read no vulnerabilities on kernels which do not report them", func() {
+		vulnerabilities, err := readCPUVulnerabilities(fstest.MapFS{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vulnerabilities).To(BeEmpty())
+	})
+
+	DescribeTable("should detect whether a vulnerability is mitigated", func(status string, mitigated bool) {
+		Expect(isMitigated(status)).To(Equal(mitigated))
+	},
+		Entry("when the cpu is not affected", "Not affected", true),
+		Entry("when the vulnerability is mitigated", "Mitigation: PTE Inversion", true),
+		Entry("when the mitigation leaves SMT vulnerable", "Mitigation: Clear CPU buffers; SMT vulnerable", false),
+		Entry("when the vulnerability is not mitigated", "Vulnerable", false),
+		Entry("when the status is unknown", "Unknown: Dependent on hypervisor status", false),
+	)
+
+	DescribeTable("should label and annotate the vulnerabilities of the reader", func(vulnerabilities map[string]string, expectedMitigated string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), cpuVulnerabilityReader: func() (map[string]string, error) {
+			return readCPUVulnerabilities(newHostFS(vulnerabilities))
+		}}
+		n.loadCPUVulnerabilities()
+
+		labels := &NodeLabels{}
+		n.addCPUVulnerabilityLabel(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.CPUVulnMitigatedLabel, expectedMitigated))
+
+		node := &v1.Node{}
+		n.setCPUVulnerabilityAnnotations(node)
+		Expect(node.Annotations).To(HaveLen(len(vulnerabilities)))
+		for vulnerability, status := range vulnerabilities {
+			Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.CPUVulnerabilityAnnotation+vulnerability, status))
+		}
+	},
+		Entry("on a mitigated host", mitigatedHost, "true"),
+		Entry("on a vulnerable host", vulnerableHost, "false"),
+	)
+
+	It("should omit the label and drop stale annotations when the vulnerabilities are unknown", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), cpuVulnerabilityReader: func() (map[string]string, error) {
+			return nil, fmt.Errorf("sysfs is not mounted")
+		}}
+		n.loadCPUVulnerabilities()
+
+		labels := &NodeLabels{}
+		n.addCPUVulnerabilityLabel(labels)
+		Expect(labels.ToMap()).ToNot(HaveKey(kubevirtv1.CPUVulnMitigatedLabel))
+
+		node := &v1.Node{}
+		node.Annotations = map[string]string{
+			kubevirtv1.CPUVulnerabilityAnnotation + "mds": "Vulnerable",
+			"unrelated": "true",
+		}
+		n.setCPUVulnerabilityAnnotations(node)
+		Expect(node.Annotations).To(Equal(map[string]string{"unrelated": "true"}))
+	})
+})
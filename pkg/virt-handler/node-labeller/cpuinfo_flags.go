@@ -0,0 +1,86 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// cpuInfoFlagsReader returns the cpu flags the kernel reports in /proc/cpuinfo
+type cpuInfoFlagsReader func() ([]string, error)
+
+// readCPUInfoFlags reads the sorted cpu flags of the first cpu, e.g. "flags : fpu vme constant_tsc"
+func readCPUInfoFlags(hostFS fs.FS) ([]string, error) {
+	if hostFS == nil {
+		return nil, fmt.Errorf("host filesystem is not available")
+	}
+	content, err := fs.ReadFile(hostFS, cpuInfoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	// the flags line of recent cpus exceeds the default token size
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || strings.TrimSpace(key) != "flags" {
+			continue
+		}
+		flags := strings.Fields(value)
+		sort.Strings(flags)
+		return flags, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%s does not contain the cpu flags", cpuInfoPath)
+}
+
+// loadCPUInfoFlags records the /proc/cpuinfo flags of the host when the secondary feature source
+// is enabled. The flags only complement the libvirt features, hence a failing read is logged
+// without blocking the labelling.
+func (n *NodeLabeller) loadCPUInfoFlags() {
+	n.cpuInfoFlags = nil
+	if !n.cpuInfoFlagsEnabled {
+		return
+	}
+
+	flags, err := n.cpuInfoFlagsReader()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not read the cpu flags of the host")
+		return
+	}
+	n.cpuInfoFlags = flags
+}
+
+// addCPUInfoFlagLabels labels the /proc/cpuinfo flags which libvirt does not report as cpu features,
+// e.g. constant_tsc. They carry their own prefix, since the kernel and libvirt name features differently.
+func (n *NodeLabeller) addCPUInfoFlagLabels(labels *NodeLabels, features cpuFeatures) {
+	for _, flag := range n.cpuInfoFlags {
+		if !features[flag] {
+			labels.CPUInfoFlags = append(labels.CPUInfoFlags, flag)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("CPU info flags", func() {
+
+	It("should read the sorted flags of the first cpu", func() {
+		flags, err := readCPUInfoFlags(os.DirFS("testdata"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(flags).To(ContainElements("constant_tsc", "nonstop_tsc", "vmx"))
+		Expect(sort.StringsAreSorted(flags)).To(BeTrue())
+	})
+
+	It("should fail without a flags line", func() {
+		hostFS := fstest.MapFS{cpuInfoPath: &fstest.MapFile{Data: []byte("processor\t: 0\nmicrocode\t: 0xd6\n")}}
+		_, err := readCPUInfoFlags(hostFS)
+		Expect(err).To(MatchError(ContainSubstring("does not contain the cpu flags")))
+	})
+
+	Context("with a flags reader", func() {
+		var n *NodeLabeller
+
+		BeforeEach(func() {
+			n = &NodeLabeller{
+				logger:              log.DefaultLogger(),
+				cpuInfoFlagsEnabled: true,
+				cpuInfoFlagsReader: func() ([]string, error) {
+					return []string{"constant_tsc", "nonstop_tsc", "vmx"}, nil
+				},
+			}
+		})
+
+		It("should only label the flags libvirt does not report", func() {
+			n.loadCPUInfoFlags()
+			labels := &NodeLabels{}
+			n.addCPUInfoFlagLabels(labels, cpuFeatures{"vmx": true, "apic": true})
+			Expect(labels.CPUInfoFlags).To(Equal([]string{"constant_tsc", "nonstop_tsc"}))
+			Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.CPUInfoFlagLabel+"constant_tsc", "true"))
+		})
+
+		It("should not label denied flags", func() {
+			n.deniedFeatures = map[string]bool{"nonstop_tsc": true}
+			n.loadCPUInfoFlags()
+			labels := &NodeLabels{}
+			n.addCPUInfoFlagLabels(labels, cpuFeatures{})
+			n.removeDeniedFeatures(labels)
+			Expect(labels.CPUInfoFlags).To(Equal([]string{"constant_tsc", "vmx"}))
+		})
+
+		It("should not read the flags unless enabled", func() {
+			n.cpuInfoFlagsEnabled = false
+			n.loadCPUInfoFlags()
+			Expect(n.cpuInfoFlags).To(BeEmpty())
+		})
+
+		It("should label no flags when the reader fails", func() {
+			n.cpuInfoFlagsReader = func() ([]string, error) {
+				return nil, fmt.Errorf("/proc/cpuinfo is not available")
+			}
+			n.loadCPUInfoFlags()
+			labels := &NodeLabels{}
+			n.addCPUInfoFlagLabels(labels, cpuFeatures{})
+			Expect(labels.CPUInfoFlags).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,66 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// deviceSource returns the number of assignable devices of the node per resource name
+type deviceSource func(node *v1.Node) map[string]int64
+
+// permittedDeviceCounts counts the allocatable devices of the node for every permitted PCI and mediated
+// host device. Resources which are not advertised on the node by a device plugin count as zero.
+func (n *NodeLabeller) permittedDeviceCounts(node *v1.Node) map[string]int64 {
+	hostDevs := n.clusterConfig.GetPermittedHostDevices()
+	if hostDevs == nil {
+		return nil
+	}
+
+	counts := make(map[string]int64)
+	count := func(resourceName string) {
+		allocatable := node.Status.Allocatable[v1.ResourceName(resourceName)]
+		counts[resourceName] = allocatable.Value()
+	}
+	for _, pciDev := range hostDevs.PciHostDevices {
+		count(pciDev.ResourceName)
+	}
+	for _, mdev := range hostDevs.MediatedDevices {
+		count(mdev.ResourceName)
+	}
+	return counts
+}
+
+// deviceCountLabelName returns the label name of the device count of the resource. The "/" between the vendor
+// and the device is not allowed in a label name, hence e.g. "nvidia.com/TU104GL_Tesla_T4" becomes
+// "nvidia.com.TU104GL_Tesla_T4", which users can select in a node selector.
+func deviceCountLabelName(resourceName string) string {
+	return strings.ReplaceAll(resourceName, "/", ".")
+}
+
+// addDeviceCountLabels labels the number of assignable devices per resource name. The device availability
+// changes with the device plugins, hence the devices are counted on every reconcile.
+func (n *NodeLabeller) addDeviceCountLabels(labels *NodeLabels, node *v1.Node) {
+	if n.deviceSource == nil {
+		return
+	}
+	labels.DeviceCounts = n.deviceSource(node)
+}
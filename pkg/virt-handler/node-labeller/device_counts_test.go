@@ -0,0 +1,80 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/kubevirt/pkg/testutils"
+)
+
+var _ = Describe("Device counts", func() {
+
+	const (
+		gpuResource  = "nvidia.com/TU104GL_Tesla_T4"
+		vgpuResource = "nvidia.com/GRID_T4-1Q"
+	)
+
+	newNodeWithAllocatable := func(allocatable v1.ResourceList) *v1.Node {
+		return &v1.Node{Status: v1.NodeStatus{Allocatable: allocatable}}
+	}
+
+	It("should count the allocatable devices of the permitted host devices", func() {
+		config, _, _ := testutils.NewFakeClusterConfigUsingKVConfig(&kubevirtv1.KubeVirtConfiguration{
+			PermittedHostDevices: &kubevirtv1.PermittedHostDevices{
+				PciHostDevices:  []kubevirtv1.PciHostDevice{{PCIVendorSelector: "10DE:1EB8", ResourceName: gpuResource}},
+				MediatedDevices: []kubevirtv1.MediatedHostDevice{{MDEVNameSelector: "GRID T4-1Q", ResourceName: vgpuResource}},
+			},
+		})
+		n := &NodeLabeller{clusterConfig: config}
+		node := newNodeWithAllocatable(v1.ResourceList{
+			gpuResource:       resource.MustParse("2"),
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		})
+		Expect(n.permittedDeviceCounts(node)).To(Equal(map[string]int64{gpuResource: 2, vgpuResource: 0}))
+	})
+
+	It("should count no devices without permitted host devices", func() {
+		config, _, _ := testutils.NewFakeClusterConfigUsingKVConfig(&kubevirtv1.KubeVirtConfiguration{})
+		n := &NodeLabeller{clusterConfig: config}
+		Expect(n.permittedDeviceCounts(newNodeWithAllocatable(v1.ResourceList{gpuResource: resource.MustParse("2")}))).To(BeEmpty())
+	})
+
+	DescribeTable("should label the device counts of the device source", func(counts map[string]int64, expectedValue string) {
+		n := &NodeLabeller{deviceSource: func(*v1.Node) map[string]int64 {
+			return counts
+		}}
+		labels := &NodeLabels{}
+		n.addDeviceCountLabels(labels, &v1.Node{})
+
+		sanitized, originalNames := sanitizeLabels(labels.ToMap())
+		Expect(sanitized).To(HaveKeyWithValue("devices.node.kubevirt.io/nvidia.com.TU104GL_Tesla_T4", expectedValue))
+		Expect(originalNames).ToNot(HaveKey("devices.node.kubevirt.io/nvidia.com.TU104GL_Tesla_T4"))
+	},
+		Entry("with assignable devices", map[string]int64{gpuResource: 2}, "2"),
+		Entry("without assignable devices", map[string]int64{gpuResource: 0}, "0"),
+	)
+})
@@ -0,0 +1,63 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+const (
+	vendorIntel = "Intel"
+	vendorAMD   = "AMD"
+)
+
+// EquivalentModels maps the cpu models of a vendor to the closest equivalent model of each other vendor
+type EquivalentModels map[string]map[string]string
+
+// NewEquivalentModels builds the equivalence table from pairs of equivalent Intel and AMD models,
+// each pair is usable in both directions
+func NewEquivalentModels(intelToAMD map[string]string) EquivalentModels {
+	models := EquivalentModels{vendorIntel: {}, vendorAMD: {}}
+	for intelModel, amdModel := range intelToAMD {
+		models[vendorAMD][intelModel] = amdModel
+		models[vendorIntel][amdModel] = intelModel
+	}
+	return models
+}
+
+// DefaultEquivalentModels pairs the Intel and AMD cpu models of roughly the same generation
+var DefaultEquivalentModels = NewEquivalentModels(map[string]string{
+	"Conroe":             "Opteron_G1",
+	"Penryn":             "Opteron_G2",
+	"Nehalem":            "Opteron_G3",
+	"SandyBridge":        "Opteron_G4",
+	"IvyBridge":          "Opteron_G5",
+	"Skylake-Server":     "EPYC",
+	"Cascadelake-Server": "EPYC-Rome",
+	"Icelake-Server":     "EPYC-Milan",
+	"SapphireRapids":     "EPYC-Genoa",
+})
+
+// EquivalentModel returns the closest equivalent of the cpu model for the target vendor.
+// Models without a known equivalent are not guessed.
+func (e EquivalentModels) EquivalentModel(model string, targetVendor string) (string, bool) {
+	equivalent, ok := e[targetVendor][model]
+	return equivalent, ok
+}
+
+// EquivalentModel returns the closest equivalent of the cpu model for the target vendor, based on the default table
+func EquivalentModel(model string, targetVendor string) (string, bool) {
+	return DefaultEquivalentModels.EquivalentModel(model, targetVendor)
+}
@@ -0,0 +1,54 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Equivalent cpu models", func() {
+
+	DescribeTable("should translate the cpu model to the target vendor", func(model, targetVendor, expectedModel string, expectedOk bool) {
+		equivalent, ok := EquivalentModel(model, targetVendor)
+		Expect(ok).To(Equal(expectedOk))
+		Expect(equivalent).To(Equal(expectedModel))
+	},
+		Entry("from Intel to AMD", "Skylake-Server", "AMD", "EPYC", true),
+		Entry("from AMD to Intel", "EPYC-Milan", "Intel", "Icelake-Server", true),
+		Entry("for an old generation", "Opteron_G2", "Intel", "Penryn", true),
+		Entry("without a mapping", "Skylake-Client-IBRS", "AMD", "", false),
+		Entry("for a model of the target vendor", "EPYC", "AMD", "", false),
+		Entry("for an unknown vendor", "EPYC", "Hygon", "", false),
+	)
+
+	It("should use an injected table", func() {
+		models := NewEquivalentModels(map[string]string{"Haswell": "EPYC"})
+		equivalent, ok := models.EquivalentModel("Haswell", "AMD")
+		Expect(ok).To(BeTrue())
+		Expect(equivalent).To(Equal("EPYC"))
+
+		equivalent, ok = models.EquivalentModel("EPYC", "Intel")
+		Expect(ok).To(BeTrue())
+		Expect(equivalent).To(Equal("Haswell"))
+
+		_, ok = models.EquivalentModel("Skylake-Server", "AMD")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,73 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"sort"
+	"strings"
+)
+
+// FeatureFamilies maps the cpu feature families to their representative features,
+// a node supporting any representative feature of a family is labelled with the family
+type FeatureFamilies map[string][]string
+
+// DefaultFeatureFamilies are the feature families labelled unless configured otherwise
+var DefaultFeatureFamilies = FeatureFamilies{
+	"sse":    {"sse4.1", "sse4.2", "ssse3"},
+	"avx":    {"avx"},
+	"avx2":   {"avx2"},
+	"avx512": {"avx512f"},
+	"aes":    {"aes"},
+	"sha":    {"sha-ni"},
+	"fma":    {"fma"},
+	"amx":    {"amx-tile"},
+}
+
+// Classify returns the sorted families of which at least one representative feature is supported
+func (f FeatureFamilies) Classify(features cpuFeatures) []string {
+	supported := make(map[string]bool, len(features))
+	for feature, ok := range features {
+		if ok {
+			supported[strings.ToLower(feature)] = true
+		}
+	}
+
+	families := []string{}
+	for family, representatives := range f {
+		for _, feature := range representatives {
+			if supported[strings.ToLower(feature)] {
+				families = append(families, family)
+				break
+			}
+		}
+	}
+	sort.Strings(families)
+	return families
+}
+
+// addFeatureFamilyLabels labels the feature families supported by the node, denied features don't count
+func (n *NodeLabeller) addFeatureFamilyLabels(labels *NodeLabels, features cpuFeatures) {
+	allowed := make(cpuFeatures, len(features))
+	for feature := range features {
+		if !n.deniedFeatures[strings.ToLower(feature)] {
+			allowed[feature] = true
+		}
+	}
+	labels.FeatureFamilies = n.featureFamilies.Classify(allowed)
+}
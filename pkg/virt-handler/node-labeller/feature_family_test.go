@@ -0,0 +1,51 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("CPU feature families", func() {
+
+	avx2Node := cpuFeatures{"sse4.2": true, "avx": true, "avx2": true, "fma": true, "vmx": true}
+
+	It("should classify a node supporting AVX2 but not AVX-512", func() {
+		Expect(DefaultFeatureFamilies.Classify(avx2Node)).To(Equal([]string{"avx", "avx2", "fma", "sse"}))
+	})
+
+	It("should classify the features with an injected table", func() {
+		families := FeatureFamilies{"vector": {"avx512f", "avx2"}, "virtualization": {"vmx", "svm"}, "crypto": {"aes"}}
+		Expect(families.Classify(avx2Node)).To(Equal([]string{"vector", "virtualization"}))
+	})
+
+	It("should not classify denied features", func() {
+		n := &NodeLabeller{
+			featureFamilies: DefaultFeatureFamilies,
+			deniedFeatures:  map[string]bool{"avx2": true},
+		}
+		labels := &NodeLabels{}
+		n.addFeatureFamilyLabels(labels, avx2Node)
+		Expect(labels.FeatureFamilies).To(ConsistOf("avx", "fma", "sse"))
+		Expect(labels.ToMap()).ToNot(HaveKey(kubevirtv1.CPUFeatureFamilyLabel + "avx2"))
+	})
+})
@@ -0,0 +1,83 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// Fingerprint returns a hash of the cpu models, host cpu features and machine types of the node.
+// Hosts with equivalent capabilities get the same fingerprint regardless of the order in which
+// libvirt reports them, which allows grouping homogeneous nodes.
+func (c cpuInfo) Fingerprint() string {
+	models := make([]string, 0, len(c.usableModels))
+	for model := range c.usableModels {
+		models = append(models, model)
+	}
+	features := make([]string, 0, len(c.hostFeatures))
+	for feature, supported := range c.hostFeatures {
+		if supported {
+			features = append(features, feature)
+		}
+	}
+
+	hash := sha256.New()
+	for _, section := range []struct {
+		name  string
+		items []string
+	}{
+		{name: "models", items: models},
+		{name: "features", items: features},
+		{name: "machines", items: c.machineTypes},
+	} {
+		fmt.Fprintf(hash, "%s:%s\n", section.name, strings.Join(sortedUnique(section.items), ","))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// sortedUnique returns a sorted copy of the items without duplicates
+func sortedUnique(items []string) []string {
+	sorted := make([]string, len(items))
+	copy(sorted, items)
+	sort.Strings(sorted)
+
+	unique := sorted[:0]
+	for i, item := range sorted {
+		if i == 0 || item != sorted[i-1] {
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+// setCPUFingerprintAnnotation records the fingerprint of the cpu capabilities of the node
+func (n *NodeLabeller) setCPUFingerprintAnnotation(node *v1.Node) {
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.CPUFingerprintAnnotation] = n.cpuInfo.Fingerprint()
+}
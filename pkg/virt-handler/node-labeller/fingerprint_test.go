@@ -0,0 +1,100 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("CPU fingerprint", func() {
+
+	newCPUInfo := func(models, features, machines []string) cpuInfo {
+		c := cpuInfo{usableModels: map[string]cpuFeatures{}, hostFeatures: cpuFeatures{}, machineTypes: machines}
+		for _, model := range models {
+			c.usableModels[model] = cpuFeatures{}
+		}
+		for _, feature := range features {
+			c.hostFeatures[feature] = true
+		}
+		return c
+	}
+
+	reference := newCPUInfo(
+		[]string{"Penryn", "Skylake-Client-IBRS", "Opteron_G1"},
+		[]string{"vmx", "ss", "pdpe1gb"},
+		[]string{"pc-q35-8.0", "q35", "pc-i440fx-8.0"},
+	)
+
+	It("should fingerprint shuffled but equal capabilities identically", func() {
+		shuffled := newCPUInfo(
+			[]string{"Opteron_G1", "Penryn", "Skylake-Client-IBRS"},
+			[]string{"pdpe1gb", "vmx", "ss"},
+			[]string{"q35", "pc-i440fx-8.0", "pc-q35-8.0", "q35"},
+		)
+		Expect(shuffled.Fingerprint()).To(Equal(reference.Fingerprint()))
+	})
+
+	It("should ignore the features of the models and the unsupported host features", func() {
+		equivalent := newCPUInfo(
+			[]string{"Penryn", "Skylake-Client-IBRS", "Opteron_G1"},
+			[]string{"vmx", "ss", "pdpe1gb"},
+			[]string{"pc-q35-8.0", "q35", "pc-i440fx-8.0"},
+		)
+		equivalent.usableModels["Penryn"] = cpuFeatures{"aes": true}
+		equivalent.hostFeatures["svm"] = false
+		Expect(equivalent.Fingerprint()).To(Equal(reference.Fingerprint()))
+	})
+
+	DescribeTable("should fingerprint different capabilities differently", func(other cpuInfo) {
+		Expect(other.Fingerprint()).ToNot(Equal(reference.Fingerprint()))
+	},
+		Entry("with a missing model", newCPUInfo(
+			[]string{"Penryn", "Skylake-Client-IBRS"},
+			[]string{"vmx", "ss", "pdpe1gb"},
+			[]string{"pc-q35-8.0", "q35", "pc-i440fx-8.0"},
+		)),
+		Entry("with an additional feature", newCPUInfo(
+			[]string{"Penryn", "Skylake-Client-IBRS", "Opteron_G1"},
+			[]string{"vmx", "ss", "pdpe1gb", "aes"},
+			[]string{"pc-q35-8.0", "q35", "pc-i440fx-8.0"},
+		)),
+		Entry("with a different machine type", newCPUInfo(
+			[]string{"Penryn", "Skylake-Client-IBRS", "Opteron_G1"},
+			[]string{"vmx", "ss", "pdpe1gb"},
+			[]string{"pc-q35-7.2", "q35", "pc-i440fx-8.0"},
+		)),
+		Entry("with a feature named like a model", newCPUInfo(
+			[]string{"Penryn", "Skylake-Client-IBRS"},
+			[]string{"vmx", "ss", "pdpe1gb", "Opteron_G1"},
+			[]string{"pc-q35-8.0", "q35", "pc-i440fx-8.0"},
+		)),
+	)
+
+	It("should annotate the node with the fingerprint", func() {
+		n := &NodeLabeller{cpuInfo: reference}
+		node := &v1.Node{}
+		n.setCPUFingerprintAnnotation(node)
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.CPUFingerprintAnnotation, reference.Fingerprint()))
+	})
+})
@@ -0,0 +1,72 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+
+	virtconfig "kubevirt.io/kubevirt/pkg/virt-config"
+	"kubevirt.io/kubevirt/pkg/virt-launcher/virtwrap/efi"
+)
+
+// ovmfPath is the directory holding the EFI roms, relative to the host root. It matches the
+// default ovmf-path of virt-launcher.
+const ovmfPath = "usr/share/OVMF"
+
+// firmwareProbe checks if the firmware binary at the given path exists on the host
+type firmwareProbe func(path string) bool
+
+// hasFirmwareFile checks if the firmware binary exists below the host root
+func (n *NodeLabeller) hasFirmwareFile(firmwarePath string) bool {
+	if n.hostFS == nil {
+		return false
+	}
+	info, err := fs.Stat(n.hostFS, firmwarePath)
+	return err == nil && !info.IsDir()
+}
+
+// detectFirmware reports whether the EFI roms needed for UEFI and for UEFI with secure boot are
+// present. Like virt-launcher, the secure boot code rom is enough for UEFI without secure boot.
+func detectFirmware(probe firmwareProbe, arch string) (uefi bool, secureBoot bool) {
+	exists := func(binary string) bool {
+		return probe(path.Join(ovmfPath, binary))
+	}
+
+	if virtconfig.IsARM64(arch) {
+		return exists(efi.EFICodeAARCH64) && exists(efi.EFIVarsAARCH64), false
+	}
+
+	secureBoot = exists(efi.EFICodeSecureBoot) && exists(efi.EFIVarsSecureBoot)
+	uefi = (exists(efi.EFICode) || exists(efi.EFICodeSecureBoot)) && exists(efi.EFIVars)
+	return uefi, secureBoot
+}
+
+// loadFirmware records which firmwares the host offers to the guests besides BIOS
+func (n *NodeLabeller) loadFirmware() {
+	n.uefiSupported, n.secureBootSupported = detectFirmware(n.firmwareProbe, runtime.GOARCH)
+}
+
+// addFirmwareLabels labels the node as supporting UEFI and secure boot or not, the labels are
+// always set so that VMs can select either state
+func (n *NodeLabeller) addFirmwareLabels(labels *NodeLabels) {
+	labels.UEFI = n.uefiSupported
+	labels.SecureBoot = n.secureBootSupported
+}
@@ -0,0 +1,77 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"path"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/kubevirt/pkg/virt-launcher/virtwrap/efi"
+)
+
+var _ = Describe("firmware support", func() {
+
+	withFirmware := func(binaries ...string) firmwareProbe {
+		return func(firmwarePath string) bool {
+			for _, binary := range binaries {
+				if firmwarePath == path.Join(ovmfPath, binary) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	DescribeTable("should label the firmwares of the firmware probe", func(arch string, probe firmwareProbe, expectedUEFI, expectedSecureBoot string) {
+		n := &NodeLabeller{}
+		n.uefiSupported, n.secureBootSupported = detectFirmware(probe, arch)
+		labels := &NodeLabels{}
+		n.addFirmwareLabels(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.UEFILabel, expectedUEFI))
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.SecureBootLabel, expectedSecureBoot))
+	},
+		Entry("without OVMF", "amd64", withFirmware(), "false", "false"),
+		Entry("with OVMF", "amd64", withFirmware(efi.EFICode, efi.EFIVars), "true", "false"),
+		Entry("with OVMF and secure boot", "amd64",
+			withFirmware(efi.EFICode, efi.EFIVars, efi.EFICodeSecureBoot, efi.EFIVarsSecureBoot), "true", "true"),
+		Entry("with the secure boot OVMF only", "amd64",
+			withFirmware(efi.EFICodeSecureBoot, efi.EFIVarsSecureBoot), "false", "true"),
+		Entry("with the secure boot code and the plain vars", "amd64",
+			withFirmware(efi.EFICodeSecureBoot, efi.EFIVars), "true", "false"),
+		Entry("with the OVMF code but no vars", "amd64", withFirmware(efi.EFICode), "false", "false"),
+		Entry("with AAVMF", "arm64", withFirmware(efi.EFICodeAARCH64, efi.EFIVarsAARCH64), "true", "false"),
+		Entry("with OVMF on arm64", "arm64",
+			withFirmware(efi.EFICode, efi.EFIVars, efi.EFICodeSecureBoot, efi.EFIVarsSecureBoot), "false", "false"),
+	)
+
+	It("should find the EFI roms below the host root", func() {
+		n := &NodeLabeller{hostFS: fstest.MapFS{
+			path.Join(ovmfPath, efi.EFICode): &fstest.MapFile{},
+			path.Join(ovmfPath, efi.EFIVars): &fstest.MapFile{},
+		}}
+		Expect(n.hasFirmwareFile(path.Join(ovmfPath, efi.EFICode))).To(BeTrue())
+		Expect(n.hasFirmwareFile(path.Join(ovmfPath, efi.EFICodeSecureBoot))).To(BeFalse())
+		Expect(n.hasFirmwareFile(ovmfPath)).To(BeFalse())
+	})
+})
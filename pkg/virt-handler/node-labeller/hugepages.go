@@ -0,0 +1,60 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+	"path"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// hugepagesPath lists the hugepage pools of the host, relative to the host root
+const hugepagesPath = "sys/kernel/mm/hugepages"
+
+// hugepageSizes are the hugepage sizes VMIs can request, along with their pool directory and label
+var hugepageSizes = []struct {
+	size  string
+	pool  string
+	label string
+}{
+	{size: "2Mi", pool: "hugepages-2048kB", label: kubevirtv1.Hugepages2MiLabel},
+	{size: "1Gi", pool: "hugepages-1048576kB", label: kubevirtv1.Hugepages1GiLabel},
+}
+
+// availableHugepageSizes returns the hugepage sizes for which the host has a nonzero pool
+func availableHugepageSizes(hostFS fs.FS) []string {
+	if hostFS == nil {
+		return nil
+	}
+
+	var sizes []string
+	for _, hugepages := range hugepageSizes {
+		pages, err := readUintFile(hostFS, path.Join(hugepagesPath, hugepages.pool, "nr_hugepages"))
+		if err == nil && pages > 0 {
+			sizes = append(sizes, hugepages.size)
+		}
+	}
+	return sizes
+}
+
+// addHugepagesLabels labels the hugepage sizes the node offers
+func (n *NodeLabeller) addHugepagesLabels(labels *NodeLabels) {
+	labels.HugepageSizes = availableHugepageSizes(n.hostFS)
+}
@@ -0,0 +1,59 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Hugepages", func() {
+
+	pool := func(pages string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(pages + "\n")}
+	}
+	const (
+		pool2Mi = hugepagesPath + "/hugepages-2048kB/nr_hugepages"
+		pool1Gi = hugepagesPath + "/hugepages-1048576kB/nr_hugepages"
+	)
+
+	DescribeTable("should detect the hugepage sizes with a nonzero pool", func(hostFS fstest.MapFS, expected []string) {
+		Expect(availableHugepageSizes(hostFS)).To(Equal(expected))
+	},
+		Entry("with both pools", fstest.MapFS{pool2Mi: pool("512"), pool1Gi: pool("4")}, []string{"2Mi", "1Gi"}),
+		Entry("with an empty 1Gi pool", fstest.MapFS{pool2Mi: pool("512"), pool1Gi: pool("0")}, []string{"2Mi"}),
+		Entry("with only a 1Gi pool", fstest.MapFS{pool1Gi: pool("4")}, []string{"1Gi"}),
+		Entry("with empty pools", fstest.MapFS{pool2Mi: pool("0"), pool1Gi: pool("0")}, nil),
+		Entry("with an unreadable pool", fstest.MapFS{pool2Mi: pool("many")}, nil),
+		Entry("without hugepages support", fstest.MapFS{}, nil),
+	)
+
+	It("should only label the hugepage sizes with a nonzero pool", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), hostFS: fstest.MapFS{pool2Mi: pool("0"), pool1Gi: pool("4")}}
+		labels := &NodeLabels{}
+		n.addHugepagesLabels(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.Hugepages1GiLabel, "true"))
+		Expect(labels.ToMap()).ToNot(HaveKey(kubevirtv1.Hugepages2MiLabel))
+	})
+})
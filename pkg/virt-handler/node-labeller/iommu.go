@@ -0,0 +1,63 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// iommuGroupsPath lists the iommu groups of the host, relative to the host root
+const iommuGroupsPath = "sys/kernel/iommu_groups"
+
+// hasIOMMU checks if the host has an active iommu, which VFIO device passthrough requires. The iommu groups
+// of the kernel are authoritative unless the kernel command line disables the iommu. Without access to the
+// iommu groups, the kernel command line has to enable the iommu explicitly.
+func hasIOMMU(hostFS fs.FS) bool {
+	if hostFS == nil {
+		return false
+	}
+
+	enabled, disabled := false, false
+	if cmdline, err := fs.ReadFile(hostFS, kernelCmdlinePath); err == nil {
+		for _, arg := range strings.Fields(string(cmdline)) {
+			switch arg {
+			case "intel_iommu=on", "amd_iommu=on":
+				enabled = true
+			case "intel_iommu=off", "amd_iommu=off", "iommu=off":
+				disabled = true
+			}
+		}
+	}
+	if disabled {
+		return false
+	}
+
+	groups, err := fs.ReadDir(hostFS, iommuGroupsPath)
+	if err != nil {
+		return enabled
+	}
+	return len(groups) > 0
+}
+
+// addIOMMULabel labels the node as supporting VFIO passthrough or not, the label is always set
+// so that VMs can select either state
+func (n *NodeLabeller) addIOMMULabel(labels *NodeLabels) {
+	labels.IOMMU = hasIOMMU(n.hostFS)
+}
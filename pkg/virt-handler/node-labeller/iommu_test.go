@@ -0,0 +1,72 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("IOMMU support", func() {
+
+	cmdline := func(args string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(args + "\n")}
+	}
+	iommuGroup := &fstest.MapFile{Mode: fs.ModeDir}
+
+	DescribeTable("should detect the iommu", func(hostFS fstest.MapFS, expected bool) {
+		Expect(hasIOMMU(hostFS)).To(Equal(expected))
+	},
+		Entry("with iommu groups", fstest.MapFS{
+			kernelCmdlinePath:      cmdline("BOOT_IMAGE=/vmlinuz root=/dev/sda1"),
+			iommuGroupsPath + "/0": iommuGroup,
+			iommuGroupsPath + "/1": iommuGroup,
+		}, true),
+		Entry("with iommu groups disabled by the kernel command line", fstest.MapFS{
+			kernelCmdlinePath:      cmdline("BOOT_IMAGE=/vmlinuz intel_iommu=off"),
+			iommuGroupsPath + "/0": iommuGroup,
+		}, false),
+		Entry("with the iommu enabled but without iommu groups", fstest.MapFS{
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz intel_iommu=on iommu=pt"),
+			iommuGroupsPath:   &fstest.MapFile{Mode: fs.ModeDir},
+		}, false),
+		Entry("with the iommu enabled and unreadable iommu groups", fstest.MapFS{
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz amd_iommu=on"),
+		}, true),
+		Entry("without any iommu hint", fstest.MapFS{
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz root=/dev/sda1"),
+		}, false),
+	)
+
+	DescribeTable("should label the iommu support explicitly", func(hostFS fstest.MapFS, expectedValue string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), hostFS: hostFS}
+		labels := &NodeLabels{}
+		n.addIOMMULabel(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.IOMMULabel, expectedValue))
+	},
+		Entry("when the iommu is enabled", fstest.MapFS{iommuGroupsPath + "/0": iommuGroup}, "true"),
+		Entry("when the iommu is disabled", fstest.MapFS{kernelCmdlinePath: cmdline("iommu=off")}, "false"),
+	)
+})
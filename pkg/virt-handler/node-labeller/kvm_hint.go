@@ -0,0 +1,29 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+// kvmHintDedicatedFeature is the KVM paravirtual hint telling the guest that its vcpus run on dedicated
+// host cpus, which lets dedicated-cpu VMs skip the paravirtual spinlock and idle optimizations
+const kvmHintDedicatedFeature = "kvm-hint-dedicated"
+
+// addKVMHintDedicatedLabel labels the node as supporting the kvm-hint-dedicated enlightenment or not,
+// the label is always set so that VMs can select either state
+func (n *NodeLabeller) addKVMHintDedicatedLabel(labels *NodeLabels, features cpuFeatures) {
+	labels.KVMHintDedicated = features[kvmHintDedicatedFeature] && !n.deniedFeatures[kvmHintDedicatedFeature]
+}
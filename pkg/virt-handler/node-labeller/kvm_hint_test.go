@@ -0,0 +1,42 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("KVM hint-dedicated", func() {
+
+	DescribeTable("should label the kvm-hint-dedicated support explicitly", func(volumePath string, deniedFeatures map[string]bool, expectedValue string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), volumePath: volumePath, deniedFeatures: deniedFeatures}
+		Expect(n.loadHostSupportedFeatures()).To(Succeed())
+		labels := &NodeLabels{}
+		n.addKVMHintDedicatedLabel(labels, n.getSupportedCpuFeatures())
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.KVMHintDedicatedLabel, expectedValue))
+	},
+		Entry("when the host advertises it", "testdata/kvm_hint_dedicated", nil, "true"),
+		Entry("when the host does not advertise it", "testdata/no_kvm_hint_dedicated", nil, "false"),
+		Entry("when it is denied", "testdata/kvm_hint_dedicated", map[string]bool{kvmHintDedicatedFeature: true}, "false"),
+	)
+})
@@ -0,0 +1,78 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"sort"
+	"strings"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/util"
+)
+
+// cpuLabelPrefixes are the prefixes of the cpu labels compared by DiffNodeLabels, including the
+// deprecated ones which previous KubeVirt versions set
+var cpuLabelPrefixes = []string{
+	util.DeprecatedLabelNamespace + util.DeprecatedcpuModelPrefix,
+	util.DeprecatedLabelNamespace + util.DeprecatedcpuFeaturePrefix,
+	kubevirtv1.CPUModelLabel,
+	kubevirtv1.SupportedHostModelMigrationCPU,
+	kubevirtv1.CPUFeatureLabel,
+	kubevirtv1.CPUModelVendorLabel,
+	kubevirtv1.CPUTimerLabel,
+	kubevirtv1.HostModelCPULabel,
+	kubevirtv1.HostModelRequiredFeaturesLabel,
+}
+
+func isCPULabel(key string) bool {
+	for _, prefix := range cpuLabelPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffNodeLabels compares the cpu labels currently set on a node with the cpu labels the node-labeller computes
+// and returns the sorted keys which are missing from the node, the stale keys which are only on the node and
+// the keys whose values differ. Labels which are not cpu labels are ignored.
+func DiffNodeLabels(current map[string]string, computed map[string]string) (missing, extra, changed []string) {
+	missing, extra, changed = []string{}, []string{}, []string{}
+	for key, value := range computed {
+		if !isCPULabel(key) {
+			continue
+		}
+		currentValue, exists := current[key]
+		if !exists {
+			missing = append(missing, key)
+		} else if currentValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range current {
+		if _, exists := computed[key]; !exists && isCPULabel(key) {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	sort.Strings(changed)
+	return missing, extra, changed
+}
@@ -0,0 +1,62 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Node label diff", func() {
+
+	It("should sort the cpu label differences into missing, extra and changed labels", func() {
+		current := map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn":           "true",
+			kubevirtv1.CPUModelLabel + "Conroe":           "true",
+			kubevirtv1.CPUFeatureLabel + "vmx":            "true",
+			kubevirtv1.CPUTimerLabel + "tsc-frequency":    "2400000000",
+			"feature.node.kubernetes.io/cpu-model-Penryn": "true",
+			kubevirtv1.HypervLabel + "synic":              "true",
+			"kubernetes.io/hostname":                      "node01",
+		}
+		computed := map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn":        "true",
+			kubevirtv1.CPUModelLabel + "Nehalem":       "true",
+			kubevirtv1.CPUFeatureLabel + "vmx":         "true",
+			kubevirtv1.CPUFeatureLabel + "aes":         "true",
+			kubevirtv1.CPUTimerLabel + "tsc-frequency": "2600000000",
+			kubevirtv1.HypervLabel + "reset":           "true",
+		}
+
+		missing, extra, changed := DiffNodeLabels(current, computed)
+		Expect(missing).To(Equal([]string{kubevirtv1.CPUFeatureLabel + "aes", kubevirtv1.CPUModelLabel + "Nehalem"}))
+		Expect(extra).To(Equal([]string{kubevirtv1.CPUModelLabel + "Conroe", "feature.node.kubernetes.io/cpu-model-Penryn"}))
+		Expect(changed).To(Equal([]string{kubevirtv1.CPUTimerLabel + "tsc-frequency"}))
+	})
+
+	It("should not report differences for identical cpu labels", func() {
+		labels := map[string]string{kubevirtv1.CPUModelLabel + "Penryn": "true"}
+		missing, extra, changed := DiffNodeLabels(labels, labels)
+		Expect(missing).To(BeEmpty())
+		Expect(extra).To(BeEmpty())
+		Expect(changed).To(BeEmpty())
+	})
+})
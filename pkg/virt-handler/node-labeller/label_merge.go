@@ -0,0 +1,48 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+// MergeNodeLabels applies the labels computed by the node-labeller to the current labels of a node. Only the
+// labels under the node-labeller prefixes, e.g. the kubevirt cpu model and cpu feature prefixes, are added,
+// updated or removed. All other labels, e.g. the scheduling labels applied by the operator, are left untouched.
+func MergeNodeLabels(current, computed map[string]string) map[string]string {
+	return mergeLabels(current, computed, isNodeLabellerLabel)
+}
+
+// mergeLabels replaces the owned labels of current with the owned labels of computed
+func mergeLabels(current, computed map[string]string, owned func(label string) bool) map[string]string {
+	merged := make(map[string]string, len(current)+len(computed))
+	for key, value := range current {
+		if !owned(key) {
+			merged[key] = value
+		}
+	}
+	for key, value := range computed {
+		if owned(key) {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// isLabellerLabel checks if the label is owned by the node-labeller, including the labels carrying
+// the configured prefix
+func (n *NodeLabeller) isLabellerLabel(label string) bool {
+	return isNodeLabellerLabel(label) || n.isPrefixedLabellerLabel(label)
+}
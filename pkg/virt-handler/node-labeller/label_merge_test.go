@@ -0,0 +1,65 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Node label merge", func() {
+
+	It("should only apply the labeller labels", func() {
+		current := map[string]string{
+			"kubernetes.io/hostname":              "node01",
+			"node-role.kubernetes.io/worker":      "",
+			kubevirtv1.CPUModelLabel + "Penryn":   "true",
+			kubevirtv1.CPUModelLabel + "Conroe":   "true",
+			kubevirtv1.CPUFeatureLabel + "vmx":    "true",
+			kubevirtv1.CPUModelCountLabel:         "2",
+			kubevirtv1.NodeSchedulable:            "true",
+			"feature.node.kubernetes.io/cpu-sse4": "true",
+		}
+		computed := map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn": "true",
+			kubevirtv1.CPUFeatureLabel + "vmx":  "true",
+			kubevirtv1.CPUFeatureLabel + "avx":  "true",
+			kubevirtv1.CPUModelCountLabel:       "1",
+			"node-role.kubernetes.io/worker":    "overridden",
+		}
+		Expect(MergeNodeLabels(current, computed)).To(Equal(map[string]string{
+			"kubernetes.io/hostname":              "node01",
+			"node-role.kubernetes.io/worker":      "",
+			kubevirtv1.CPUModelLabel + "Penryn":   "true",
+			kubevirtv1.CPUFeatureLabel + "vmx":    "true",
+			kubevirtv1.CPUFeatureLabel + "avx":    "true",
+			kubevirtv1.CPUModelCountLabel:         "1",
+			kubevirtv1.NodeSchedulable:            "true",
+			"feature.node.kubernetes.io/cpu-sse4": "true",
+		}))
+	})
+
+	It("should not modify the current labels", func() {
+		current := map[string]string{kubevirtv1.CPUModelLabel + "Conroe": "true"}
+		Expect(MergeNodeLabels(current, nil)).To(BeEmpty())
+		Expect(current).To(HaveKey(kubevirtv1.CPUModelLabel + "Conroe"))
+	})
+})
@@ -0,0 +1,84 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	missingRequiredCapability = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubevirt_node_missing_required_capability",
+			Help: "Indication for a capability required by the cluster CPU policy which the node is missing.",
+		},
+		[]string{"capability"},
+	)
+
+	nodeCPUFeature = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubevirt_node_cpu_feature",
+			Help: "Indication for a cpu feature the node-labeller labelled on the node.",
+		},
+		[]string{"feature"},
+	)
+
+	reconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubevirt_nodelabeller_reconcile_total",
+			Help: "The total number of node-labeller reconciles by result.",
+		},
+		[]string{"result"},
+	)
+)
+
+const (
+	reconcileResultSuccess    = "success"
+	reconcileResultParseError = "parse_error"
+	reconcileResultApplyError = "apply_error"
+	reconcileResultNoop       = "noop"
+)
+
+func init() {
+	prometheus.MustRegister(missingRequiredCapability)
+	prometheus.MustRegister(nodeCPUFeature)
+	prometheus.MustRegister(reconcileTotal)
+}
+
+func reportReconcileResult(result string) {
+	reconcileTotal.WithLabelValues(result).Inc()
+}
+
+// reportMissingRequiredCapabilities replaces the previously reported missing capabilities
+func reportMissingRequiredCapabilities(capabilities []string) {
+	missingRequiredCapability.Reset()
+	for _, capability := range capabilities {
+		missingRequiredCapability.WithLabelValues(capability).Set(1)
+	}
+}
+
+// reportCPUFeatures replaces the previously reported cpu features, so that the features which
+// disappeared from the node are no longer reported
+func reportCPUFeatures(features []string) {
+	nodeCPUFeature.Reset()
+	for _, feature := range features {
+		nodeCPUFeature.WithLabelValues(feature).Set(1)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// cpuInfoPath is the path of the kernel cpu information, relative to the host root
+const cpuInfoPath = "proc/cpuinfo"
+
+// getMicrocodeRevision returns the microcode revision of the host cpu as a decimal number. It is read
+// from the host capabilities and falls back to /proc/cpuinfo. A change since the previous call is logged.
+func (n *NodeLabeller) getMicrocodeRevision() (string, bool) {
+	revision, ok := "", false
+	if n.capabilities != nil {
+		revision, ok = n.capabilities.GetMicrocodeVersion()
+	}
+	if !ok {
+		revision, ok = readMicrocodeFromCPUInfo(n.hostFS)
+	}
+	if !ok {
+		return "", false
+	}
+
+	if n.microcodeRevision != "" && n.microcodeRevision != revision {
+		n.logger.Infof("node-labeller detected a microcode update from revision %s to %s", n.microcodeRevision, revision)
+	}
+	n.microcodeRevision = revision
+	return revision, true
+}
+
+// readMicrocodeFromCPUInfo reads the microcode revision of the first cpu, e.g. "microcode : 0xd6"
+func readMicrocodeFromCPUInfo(hostFS fs.FS) (string, bool) {
+	if hostFS == nil {
+		return "", false
+	}
+	content, err := fs.ReadFile(hostFS, cpuInfoPath)
+	if err != nil {
+		return "", false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || strings.TrimSpace(key) != "microcode" {
+			continue
+		}
+		revision, err := strconv.ParseUint(strings.TrimSpace(value), 0, 64)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatUint(revision, 10), true
+	}
+	return "", false
+}
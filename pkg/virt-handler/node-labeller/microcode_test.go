@@ -0,0 +1,76 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/client-go/log"
+
+	"kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/api"
+)
+
+var _ = Describe("CPU microcode", func() {
+
+	cpuInfo := func(microcode string) fstest.MapFS {
+		return fstest.MapFS{
+			"proc/cpuinfo": {Data: []byte("processor\t: 0\nvendor_id\t: GenuineIntel\nmicrocode\t: " + microcode + "\ncpu MHz\t\t: 2400.000\n")},
+		}
+	}
+
+	DescribeTable("should read the microcode revision from /proc/cpuinfo", func(hostFS fstest.MapFS, expectedOk bool, expectedRevision string) {
+		revision, ok := readMicrocodeFromCPUInfo(hostFS)
+		Expect(ok).To(Equal(expectedOk))
+		Expect(revision).To(Equal(expectedRevision))
+	},
+		Entry("with a hexadecimal revision", cpuInfo("0xd6"), true, "214"),
+		Entry("with a decimal revision", cpuInfo("214"), true, "214"),
+		Entry("with an unparsable revision", cpuInfo("unknown"), false, ""),
+		Entry("without a microcode line", fstest.MapFS{"proc/cpuinfo": {Data: []byte("processor\t: 0\n")}}, false, ""),
+		Entry("without /proc/cpuinfo", fstest.MapFS{}, false, ""),
+	)
+
+	It("should prefer the host capabilities over /proc/cpuinfo", func() {
+		n := &NodeLabeller{
+			logger:       log.DefaultLogger(),
+			hostFS:       cpuInfo("0x1"),
+			capabilities: &api.Capabilities{Host: api.Host{CPU: api.HostCPU{Microcode: &api.Microcode{Version: "214"}}}},
+		}
+		revision, ok := n.getMicrocodeRevision()
+		Expect(ok).To(BeTrue())
+		Expect(revision).To(Equal("214"))
+	})
+
+	It("should track the revision across microcode updates", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), hostFS: cpuInfo("0xd6")}
+		revision, ok := n.getMicrocodeRevision()
+		Expect(ok).To(BeTrue())
+		Expect(revision).To(Equal("214"))
+
+		n.hostFS = cpuInfo("0xf0")
+		revision, ok = n.getMicrocodeRevision()
+		Expect(ok).To(BeTrue())
+		Expect(revision).To(Equal("240"))
+		Expect(n.microcodeRevision).To(Equal("240"))
+	})
+})
@@ -18,12 +18,38 @@
 
 package nodelabeller
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "kubevirt.io/api/core/v1"
+)
+
 type cpuFeatures map[string]bool
 
 type supportedFeatures struct {
 	items []string
 }
 
+// newSupportedFeatures returns the supported features sorted, so that the labels and
+// anything derived from them don't depend on the discovery order
+func newSupportedFeatures(items []string) supportedFeatures {
+	sorted := make([]string, len(items))
+	copy(sorted, items)
+	sort.Strings(sorted)
+	return supportedFeatures{items: sorted}
+}
+
+// Items returns a copy of the sorted supported features
+func (f supportedFeatures) Items() []string {
+	items := make([]string, len(f.items))
+	copy(items, f.items)
+	return items
+}
+
 type hostCPUModel struct {
 	Name             string
 	fallback         string
@@ -34,12 +60,358 @@ type hostCPUModel struct {
 // so we don't have to call libvirt at every request
 type cpuInfo struct {
 	usableModels map[string]cpuFeatures
+	hostFeatures cpuFeatures
+	// migratableHostFeatures are the host features which can be live migrated
+	migratableHostFeatures cpuFeatures
+	// modelUsability holds whether libvirt reports each cpu model of the domain capabilities as usable
+	modelUsability map[string]bool
+	// machineTypes are the machine types supported by the hypervisor
+	machineTypes []string
+}
+
+// SupportsFeatures reports whether the features of the cpu model together with the features
+// supported by the host cover all the required features, and returns the missing ones.
+// Feature names are compared case-insensitively and may carry the "+" or "-" prefix used in
+// VMI specs, features prefixed with "-" are disabled and therefore never missing.
+func (c cpuInfo) SupportsFeatures(model string, required []string) (bool, []string) {
+	available := make(map[string]bool, len(c.usableModels[model])+len(c.hostFeatures))
+	for feature := range c.usableModels[model] {
+		available[strings.ToLower(feature)] = true
+	}
+	for feature := range c.hostFeatures {
+		available[strings.ToLower(feature)] = true
+	}
+
+	missing := make([]string, 0)
+	for _, feature := range required {
+		if strings.HasPrefix(feature, "-") {
+			continue
+		}
+		feature = strings.TrimPrefix(feature, "+")
+		if !available[strings.ToLower(feature)] {
+			missing = append(missing, feature)
+		}
+	}
+	return len(missing) == 0, missing
+}
+
+// CommonFeatures returns the sorted features which are part of every usable cpu model, i.e. the
+// features a custom cpu can safely require on the host. It is empty if no model is usable.
+func (c cpuInfo) CommonFeatures() []string {
+	common := []string{}
+	first := true
+	for _, features := range c.usableModels {
+		if first {
+			for feature := range features {
+				common = append(common, feature)
+			}
+			first = false
+			continue
+		}
+
+		remaining := common[:0]
+		for _, feature := range common {
+			if features[feature] {
+				remaining = append(remaining, feature)
+			}
+		}
+		common = remaining
+	}
+	sort.Strings(common)
+	return common
+}
+
+// ModelUsability returns the number of usable cpu models and the total number of cpu models
+// reported by libvirt
+func (c cpuInfo) ModelUsability() (usable, total int) {
+	for _, isUsable := range c.modelUsability {
+		if isUsable {
+			usable++
+		}
+	}
+	return usable, len(c.modelUsability)
+}
+
+// NewlyUnusable returns the sorted cpu models which libvirt reported as usable in the previous
+// cpu info but not anymore, e.g. because a kernel mitigation disabled a feature they require
+func (c cpuInfo) NewlyUnusable(prev cpuInfo) []string {
+	models := []string{}
+	for model, wasUsable := range prev.modelUsability {
+		if wasUsable && !c.modelUsability[model] {
+			models = append(models, model)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
+
+// FeatureDelta returns the sorted features the target cpu model adds over the base cpu model and
+// the sorted features of the base model the target model lacks, e.g. to show what migrating a VM
+// from the base model to the target model gains or loses
+func (c cpuInfo) FeatureDelta(baseModel, targetModel string) (added, removed []string, err error) {
+	baseFeatures, exists := c.usableModels[baseModel]
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown cpu model %s", baseModel)
+	}
+	targetFeatures, exists := c.usableModels[targetModel]
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown cpu model %s", targetModel)
+	}
+
+	added, removed = []string{}, []string{}
+	for feature := range targetFeatures {
+		if !baseFeatures[feature] {
+			added = append(added, feature)
+		}
+	}
+	for feature := range baseFeatures {
+		if !targetFeatures[feature] {
+			removed = append(removed, feature)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, nil
+}
+
+// isUsableModel checks if the cpu model is known and not reported as unusable by libvirt
+func (c cpuInfo) isUsableModel(model string) bool {
+	if _, exists := c.usableModels[model]; !exists {
+		return false
+	}
+	usable, reported := c.modelUsability[model]
+	return !reported || usable
+}
+
+// MigrationCompatible checks if a VM using the cpu model and the required features can live migrate from the
+// source to the destination host, and returns the reasons blocking the migration. The destination has to
+// support the model and every required feature the VM is able to use on the source, features the source
+// lacks can't be in use and do not block the migration. Features in use which the source can't live migrate,
+// e.g. invtsc, block the migration regardless of the destination.
+func MigrationCompatible(src, dst cpuInfo, model string, features []string) (bool, []string) {
+	reasons := []string{}
+	if !dst.isUsableModel(model) {
+		reasons = append(reasons, fmt.Sprintf("cpu model %s is not usable on the destination", model))
+	}
+
+	_, missingOnSrc := src.SupportsFeatures(model, features)
+	unusedFeatures := make(map[string]bool, len(missingOnSrc))
+	for _, feature := range missingOnSrc {
+		unusedFeatures[strings.ToLower(feature)] = true
+	}
+	usedFeatures := make([]string, 0, len(features))
+	for _, feature := range features {
+		if !unusedFeatures[strings.ToLower(strings.TrimPrefix(feature, "+"))] {
+			usedFeatures = append(usedFeatures, feature)
+		}
+	}
+
+	migratableSrc := cpuInfo{usableModels: src.usableModels, hostFeatures: src.migratableHostFeatures}
+	_, notMigratable := migratableSrc.SupportsFeatures(model, usedFeatures)
+	sort.Strings(notMigratable)
+	nonMigratableFeatures := make(map[string]bool, len(notMigratable))
+	for _, feature := range notMigratable {
+		nonMigratableFeatures[strings.ToLower(feature)] = true
+		reasons = append(reasons, fmt.Sprintf("cpu feature %s can not be live migrated from the source", feature))
+	}
+	migratableFeatures := make([]string, 0, len(usedFeatures))
+	for _, feature := range usedFeatures {
+		if !nonMigratableFeatures[strings.ToLower(strings.TrimPrefix(feature, "+"))] {
+			migratableFeatures = append(migratableFeatures, feature)
+		}
+	}
+
+	_, missingOnDst := dst.SupportsFeatures(model, migratableFeatures)
+	sort.Strings(missingOnDst)
+	for _, feature := range missingOnDst {
+		reasons = append(reasons, fmt.Sprintf("cpu feature %s is not supported on the destination", feature))
+	}
+	return len(reasons) == 0, reasons
+}
+
+// RecommendedGroupModel returns the cpu model and the features a VM can use to live migrate between all the
+// hosts of a group. The model is the richest one, i.e. the one with the most features, usable on every host,
+// ties are broken by name. The features are the sorted migratable host features every host supports on top of
+// the model. ok is false if the group is empty or its hosts do not share a usable model.
+func RecommendedGroupModel(infos []cpuInfo) (model string, features []string, ok bool) {
+	if len(infos) == 0 {
+		return "", nil, false
+	}
+
+	usableOnAll := func(model string) bool {
+		for _, info := range infos {
+			if !info.isUsableModel(model) {
+				return false
+			}
+		}
+		return true
+	}
+	for candidate, candidateFeatures := range infos[0].usableModels {
+		if !usableOnAll(candidate) {
+			continue
+		}
+		if model != "" {
+			modelFeatures := infos[0].usableModels[model]
+			if len(candidateFeatures) < len(modelFeatures) || (len(candidateFeatures) == len(modelFeatures) && candidate > model) {
+				continue
+			}
+		}
+		model = candidate
+	}
+	if model == "" {
+		return "", nil, false
+	}
+
+	features = []string{}
+	for feature := range infos[0].migratableHostFeatures {
+		if infos[0].usableModels[model][feature] {
+			continue
+		}
+		supportedOnAll := true
+		for _, info := range infos[1:] {
+			supportedOnAll = supportedOnAll && info.migratableHostFeatures[feature]
+		}
+		if supportedOnAll {
+			features = append(features, feature)
+		}
+	}
+	sort.Strings(features)
+	return model, features, true
 }
 
 // HostDomCapabilities represents structure for parsing output of virsh capabilities
 type HostDomCapabilities struct {
-	CPU CPU              `xml:"cpu"`
-	SEV SEVConfiguration `xml:"features>sev"`
+	Domain   string           `xml:"domain"`
+	Machines []string         `xml:"machine"`
+	Arch     string           `xml:"arch"`
+	VCPU     VCPU             `xml:"vcpu"`
+	CPU      CPU              `xml:"cpu"`
+	SEV      SEVConfiguration `xml:"features>sev"`
+	GIC      GIC              `xml:"features>gic"`
+	Hyperv   Hyperv           `xml:"features>hyperv"`
+	Devices  Devices          `xml:"devices"`
+}
+
+// ModelUsability maps the named cpu models libvirt reports a usability for to whether they are usable
+func (h HostDomCapabilities) ModelUsability() map[string]bool {
+	modelUsability := make(map[string]bool)
+	for _, mode := range h.CPU.Mode {
+		for _, model := range mode.Model {
+			if model.Usable == "" || strings.TrimSpace(model.Name) == "" {
+				continue
+			}
+			modelUsability[model.Name] = model.Usable != isUnusable
+		}
+	}
+	return modelUsability
+}
+
+// HostModel returns the cpu model libvirt picks for the host-model cpu mode, together with the
+// feature deltas on top of it, "+feature" for required and "-feature" for disabled features.
+// ok is false if the host-model mode is unsupported or does not name a model.
+func (h HostDomCapabilities) HostModel() (model string, features []string, ok bool) {
+	for _, mode := range h.CPU.Mode {
+		if mode.Name != v1.CPUModeHostModel || mode.Supported == "no" {
+			continue
+		}
+		if len(mode.Model) == 0 || strings.TrimSpace(mode.Model[0].Name) == "" {
+			return "", nil, false
+		}
+
+		features = []string{}
+		for _, feature := range mode.Feature {
+			switch feature.Policy {
+			case isRequired:
+				features = append(features, "+"+feature.Name)
+			case "disable":
+				features = append(features, "-"+feature.Name)
+			}
+		}
+		return strings.TrimSpace(mode.Model[0].Name), features, true
+	}
+	return "", nil, false
+}
+
+// cpuHotplugArchs are the architectures on which QEMU can hotplug vCPUs
+var cpuHotplugArchs = map[string]bool{
+	"x86_64":  true,
+	"ppc64le": true,
+	"s390x":   true,
+}
+
+// SupportsCPUHotplug reports whether vCPUs can be hotplugged to running domains. libvirt does not advertise
+// the capability itself, it is derived from a KVM hypervisor on an architecture supporting vCPU hotplug which
+// allows more than a single vCPU per domain.
+func (h HostDomCapabilities) SupportsCPUHotplug() bool {
+	return h.Domain == "kvm" && cpuHotplugArchs[h.Arch] && h.VCPU.GetMax() > 1
+}
+
+// machineTypeAliases maps the unversioned machine types whose versioned names use a different prefix
+var machineTypeAliases = map[string]string{
+	"pc": "pc-i440fx",
+}
+
+var machineTypeVersionNumbers = regexp.MustCompile(`\d+`)
+
+// SupportsMachine resolves the machine type against the machine types supported by the hypervisor.
+// An unversioned machine type, e.g. q35, resolves to the highest supported version of it, e.g.
+// pc-q35-8.0, while an explicitly versioned machine type has to be supported as is.
+func (h HostDomCapabilities) SupportsMachine(name string) (resolved string, ok bool) {
+	prefix := name
+	if alias, exists := machineTypeAliases[name]; exists {
+		prefix = alias
+	}
+
+	var resolvedVersion []int
+	for _, machine := range h.Machines {
+		if machine == name {
+			return machine, true
+		}
+
+		var version string
+		if strings.HasPrefix(machine, "pc-"+prefix+"-") {
+			version = strings.TrimPrefix(machine, "pc-"+prefix+"-")
+		} else if strings.HasPrefix(machine, prefix+"-") {
+			version = strings.TrimPrefix(machine, prefix+"-")
+		} else {
+			continue
+		}
+
+		machineVersion := parseMachineTypeVersion(version)
+		if machineVersion == nil {
+			continue
+		}
+		if resolvedVersion == nil || compareMachineTypeVersions(machineVersion, resolvedVersion) > 0 {
+			resolved, resolvedVersion = machine, machineVersion
+		}
+	}
+	return resolved, resolvedVersion != nil
+}
+
+// parseMachineTypeVersion returns the version numbers of a machine type version, e.g. [9 2 0] for
+// rhel9.2.0, or nil if it is not a version
+func parseMachineTypeVersion(version string) []int {
+	if version == "" || strings.Contains(version, "-") {
+		return nil
+	}
+	var numbers []int
+	for _, number := range machineTypeVersionNumbers.FindAllString(version, -1) {
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return nil
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+func compareMachineTypeVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
 }
 
 // CPU represents slice of cpu modes
@@ -57,7 +429,7 @@ type Mode struct {
 }
 
 type SupportedHostFeature struct {
-	Feature []HostFeature `xml:"feature"`
+	Feature []Feature `xml:"feature"`
 }
 
 type HostFeature struct {
@@ -88,6 +460,35 @@ type Features struct {
 
 type Feature struct {
 	Name string `xml:"name,attr"`
+	// Migratable is "no" for features which can't be live migrated, e.g. invtsc
+	Migratable string `xml:"migratable,attr"`
+	// Policy is "optional" for features the model can do without, features are required by default
+	Policy string `xml:"policy,attr"`
+}
+
+// MigratableFeatures returns the features which can be live migrated,
+// features without the migratable attribute are migratable
+func (f Features) MigratableFeatures() []Feature {
+	migratable := make([]Feature, 0, len(f.Features))
+	for _, feature := range f.Features {
+		if feature.Migratable == isNotMigratable {
+			continue
+		}
+		migratable = append(migratable, feature)
+	}
+	return migratable
+}
+
+// RequiredFeatures returns the features which have to be present on a host to use the model,
+// which excludes the optional features
+func (f Features) RequiredFeatures() cpuFeatures {
+	required := make(cpuFeatures, len(f.Features))
+	for _, feature := range f.Features {
+		if feature.Policy == "" || feature.Policy == isRequired {
+			required[feature.Name] = true
+		}
+	}
+	return required
 }
 
 type SEVConfiguration struct {
@@ -98,3 +499,158 @@ type SEVConfiguration struct {
 	MaxESGuests     uint   `xml:"maxESGuests"`
 	SupportedES     string `xml:"-"`
 }
+
+// GIC represents the arm64 generic interrupt controller capabilities
+type GIC struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// Versions returns the GIC versions supported by the hypervisor
+func (g GIC) Versions() []string {
+	if g.Supported != isSupported {
+		return nil
+	}
+	return enumValues(g.Enum, "version")
+}
+
+// Hyperv represents the Hyper-V enlightenment capabilities of the hypervisor
+type Hyperv struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// SupportsVendorID reports whether the hypervisor can present a custom vendor_id to the guest
+func (h Hyperv) SupportsVendorID() bool {
+	return h.Supported == isSupported && hasEnumValue(h.Enum, "features", "vendor_id")
+}
+
+// Devices represents the device capabilities of the hypervisor
+type Devices struct {
+	Disk     Disk         `xml:"disk"`
+	IOMMU    IOMMU        `xml:"iommu"`
+	Memory   MemoryDevice `xml:"memory"`
+	Video    Video        `xml:"video"`
+	Graphics Graphics     `xml:"graphics"`
+	Watchdog Watchdog     `xml:"watchdog"`
+	TPM      TPM          `xml:"tpm"`
+}
+
+// SupportsVirgl reports whether 3D accelerated graphics through virgl are supported. This requires
+// the virtio video model and an OpenGL capable display, which QEMU builds without virgl lack.
+func (d Devices) SupportsVirgl() bool {
+	return d.Video.Supported == isSupported && hasEnumValue(d.Video.Enum, "modelType", "virtio") &&
+		d.Graphics.Supported == isSupported && hasEnumValue(d.Graphics.Enum, "type", "egl-headless")
+}
+
+// VCPU represents the vCPU limits of the hypervisor
+type VCPU struct {
+	// Max is the maximum number of vCPUs of a domain, it is 0 if absent
+	Max int `xml:"max,attr"`
+}
+
+// GetMax returns the maximum number of vCPUs of a domain, or 0 if it is unknown
+func (v VCPU) GetMax() int {
+	if v.Max < 0 {
+		return 0
+	}
+	return v.Max
+}
+
+// Disk represents the disk device capabilities
+type Disk struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// IOMMU represents the iommu device capabilities
+type IOMMU struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// MemoryDevice represents the memory device capabilities
+type MemoryDevice struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// Video represents the video device capabilities
+type Video struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// Graphics represents the graphics device capabilities
+type Graphics struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// Watchdog represents the watchdog device capabilities
+type Watchdog struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// TPM represents the TPM device capabilities
+type TPM struct {
+	Supported string `xml:"supported,attr"`
+	Enum      []Enum `xml:"enum"`
+}
+
+// Enum represents a named list of values supported by the hypervisor
+type Enum struct {
+	Name  string   `xml:"name,attr"`
+	Value []string `xml:"value"`
+}
+
+// AIOModes returns the disk aio modes supported by the hypervisor
+func (d Disk) AIOModes() []string {
+	if d.Supported != isSupported {
+		return nil
+	}
+	return enumValues(d.Enum, "aio")
+}
+
+// Actions returns the actions the hypervisor can take when the guest watchdog expires
+func (w Watchdog) Actions() []string {
+	if w.Supported != isSupported {
+		return nil
+	}
+	return enumValues(w.Enum, "action")
+}
+
+// SupportsVirtIO reports whether the virtio-iommu model is supported by the hypervisor
+func (i IOMMU) SupportsVirtIO() bool {
+	return i.Supported == isSupported && hasEnumValue(i.Enum, "model", "virtio")
+}
+
+// SupportsEmulator reports whether a TPM can be emulated by swtpm
+func (t TPM) SupportsEmulator() bool {
+	return t.Supported == isSupported && hasEnumValue(t.Enum, "backendModel", "emulator")
+}
+
+// SupportsHotUnplug reports whether memory can be hot-unplugged from the guest. This requires the
+// virtio-mem model, since unplugging dimm devices depends on the cooperation of the guest.
+func (m MemoryDevice) SupportsHotUnplug() bool {
+	return m.Supported == isSupported && hasEnumValue(m.Enum, "model", "virtio-mem")
+}
+
+func hasEnumValue(enums []Enum, name, value string) bool {
+	for _, v := range enumValues(enums, name) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func enumValues(enums []Enum, name string) []string {
+	for _, enum := range enums {
+		if enum.Name == name {
+			return enum.Value
+		}
+	}
+	return nil
+}
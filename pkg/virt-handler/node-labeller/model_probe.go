@@ -0,0 +1,64 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// ModelProbe checks that a domain using the given cpu model can actually be defined on the host
+type ModelProbe func(model string) error
+
+// probeCPUModels drops the cpu models rejected by the model probe and returns them separately,
+// all models are kept when no probe is configured
+func (n *NodeLabeller) probeCPUModels(models []string) (accepted, rejected []string) {
+	if n.modelProbe == nil {
+		return models, nil
+	}
+
+	accepted = make([]string, 0, len(models))
+	for _, model := range models {
+		if err := n.modelProbe(model); err != nil {
+			n.logger.Reason(err).Warningf("cpu model %s is reported usable but failed the define probe, it is not labelled", model)
+			rejected = append(rejected, model)
+			continue
+		}
+		accepted = append(accepted, model)
+	}
+	sort.Strings(rejected)
+	return accepted, rejected
+}
+
+// setRejectedModelsAnnotation lists the cpu models which failed the define probe on the node,
+// the annotation is removed when no model was rejected
+func setRejectedModelsAnnotation(node *v1.Node, rejected []string) {
+	if len(rejected) == 0 {
+		delete(node.Annotations, kubevirtv1.LabellerRejectedModelsAnnotation)
+		return
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.LabellerRejectedModelsAnnotation] = strings.Join(rejected, ",")
+}
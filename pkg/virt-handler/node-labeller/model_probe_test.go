@@ -0,0 +1,65 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("CPU model probe", func() {
+
+	It("should keep all models without a probe", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger()}
+		accepted, rejected := n.probeCPUModels([]string{"Penryn", "Nehalem"})
+		Expect(accepted).To(Equal([]string{"Penryn", "Nehalem"}))
+		Expect(rejected).To(BeEmpty())
+	})
+
+	It("should reject the models failing the probe", func() {
+		n := &NodeLabeller{
+			logger: log.DefaultLogger(),
+			modelProbe: func(model string) error {
+				if model == "Penryn" || model == "EPYC" {
+					return fmt.Errorf("cpu model %s can not be defined", model)
+				}
+				return nil
+			},
+		}
+		accepted, rejected := n.probeCPUModels([]string{"Penryn", "Nehalem", "EPYC"})
+		Expect(accepted).To(Equal([]string{"Nehalem"}))
+		Expect(rejected).To(Equal([]string{"EPYC", "Penryn"}))
+	})
+
+	It("should list the rejected models on the node", func() {
+		node := &v1.Node{}
+		setRejectedModelsAnnotation(node, []string{"EPYC", "Penryn"})
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.LabellerRejectedModelsAnnotation, "EPYC,Penryn"))
+
+		setRejectedModelsAnnotation(node, nil)
+		Expect(node.Annotations).ToNot(HaveKey(kubevirtv1.LabellerRejectedModelsAnnotation))
+	})
+})
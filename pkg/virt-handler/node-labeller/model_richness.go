@@ -0,0 +1,51 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"sort"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// ModelRichnessScore returns the number of cpu models usable on the node. Nodes able to run
+// a wider range of cpu models are better targets for migratable VMs.
+func ModelRichnessScore(c cpuInfo) int {
+	return len(c.usableModels)
+}
+
+// SortNodesByModelRichness sorts the nodes by their cpu model count label, richest first.
+// Nodes without a valid label are sorted last, the order of nodes with equal scores is kept.
+func SortNodesByModelRichness(nodes []*v1.Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodeModelRichness(nodes[i]) > nodeModelRichness(nodes[j])
+	})
+}
+
+func nodeModelRichness(node *v1.Node) int {
+	count, err := strconv.Atoi(node.Labels[kubevirtv1.CPUModelCountLabel])
+	if err != nil {
+		return -1
+	}
+	return count
+}
@@ -0,0 +1,71 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Model richness", func() {
+
+	DescribeTable("should score the node by its usable cpu models", func(c cpuInfo, expectedScore int) {
+		Expect(ModelRichnessScore(c)).To(Equal(expectedScore))
+	},
+		Entry("without usable models", cpuInfo{}, 0),
+		Entry("with a single usable model", cpuInfo{usableModels: map[string]cpuFeatures{"Penryn": {}}}, 1),
+		Entry("with several usable models", cpuInfo{usableModels: map[string]cpuFeatures{
+			"Penryn":              {},
+			"Skylake-Client-IBRS": {"ss": true},
+			"Opteron_G1":          {},
+		}}, 3),
+	)
+
+	It("should sort the nodes by their cpu model count, richest first", func() {
+		modelCountNode := func(name, count string) *v1.Node {
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+			if count != "" {
+				node.Labels[kubevirtv1.CPUModelCountLabel] = count
+			}
+			return node
+		}
+		nodes := []*v1.Node{
+			modelCountNode("few", "2"),
+			modelCountNode("unlabelled", ""),
+			modelCountNode("many", "12"),
+			modelCountNode("invalid", "many"),
+			modelCountNode("some", "5"),
+			modelCountNode("some-too", "5"),
+		}
+
+		SortNodesByModelRichness(nodes)
+
+		names := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		Expect(names).To(Equal([]string{"many", "some", "some-too", "few", "unlabelled", "invalid"}))
+	})
+})
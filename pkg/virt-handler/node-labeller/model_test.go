@@ -0,0 +1,600 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"sort"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	k8sv1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+// loadDomCapabilitiesFixture unmarshals the given testdata fixture into HostDomCapabilities
+func loadDomCapabilitiesFixture(fileName string) HostDomCapabilities {
+	data, err := os.ReadFile(filepath.Join("testdata", fileName))
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	domCapabilities := HostDomCapabilities{}
+	ExpectWithOffset(1, xml.Unmarshal(data, &domCapabilities)).To(Succeed())
+	return domCapabilities
+}
+
+func getMode(domCapabilities HostDomCapabilities, name string) Mode {
+	for _, mode := range domCapabilities.CPU.Mode {
+		if mode.Name == name {
+			return mode
+		}
+	}
+	Fail("cpu mode " + name + " not found")
+	return Mode{}
+}
+
+func getModelUsability(mode Mode) map[string]string {
+	models := make(map[string]string)
+	for _, model := range mode.Model {
+		models[model.Name] = model.Usable
+	}
+	return models
+}
+
+func getFeaturePolicies(mode Mode) map[string]string {
+	features := make(map[string]string)
+	for _, feature := range mode.Feature {
+		features[feature.Name] = feature.Policy
+	}
+	return features
+}
+
+var _ = Describe("Domain capabilities model", func() {
+
+	It("should parse the cpu modes", func() {
+		domCapabilities := loadDomCapabilitiesFixture("domcapabilities_intel.xml")
+
+		modes := make(map[string]string)
+		for _, mode := range domCapabilities.CPU.Mode {
+			modes[mode.Name] = mode.Supported
+		}
+		Expect(modes).To(Equal(map[string]string{
+			"host-passthrough": "yes",
+			"maximum":          "yes",
+			"host-model":       "yes",
+			"custom":           "yes",
+		}))
+	})
+
+	DescribeTable("should parse the host model", func(fileName, model, vendor string, features map[string]string) {
+		hostModel := getMode(loadDomCapabilitiesFixture(fileName), "host-model")
+
+		Expect(hostModel.Model).To(HaveLen(1))
+		Expect(hostModel.Model[0].Name).To(Equal(model))
+		Expect(hostModel.Model[0].Fallback).To(Equal("forbid"))
+		Expect(hostModel.Vendor.Name).To(Equal(vendor))
+		Expect(getFeaturePolicies(hostModel)).To(Equal(features))
+	},
+		Entry("on an Intel host", "domcapabilities_intel.xml", "Icelake-Server", "Intel", map[string]string{
+			"ss":                "require",
+			"vmx":               "require",
+			"pdcm":              "require",
+			"hypervisor":        "require",
+			"tsc_adjust":        "require",
+			"arch-capabilities": "require",
+			"invtsc":            "require",
+			"mpx":               "disable",
+			"hle":               "disable",
+			"rtm":               "disable",
+		}),
+		Entry("on an AMD host", "domcapabilities_amd.xml", "EPYC-Milan", "AMD", map[string]string{
+			"x2apic":     "require",
+			"hypervisor": "require",
+			"svm":        "require",
+			"npt":        "require",
+			"nrip-save":  "require",
+			"invtsc":     "require",
+			"monitor":    "disable",
+		}),
+		Entry("on a nested virtualization host", "domcapabilities_nested.xml", "Skylake-Client-IBRS", "Intel", map[string]string{
+			"hypervisor": "require",
+			"vmx":        "require",
+			"ssbd":       "require",
+			"invtsc":     "disable",
+			"pdpe1gb":    "disable",
+		}),
+	)
+
+	DescribeTable("should resolve the host model to a concrete model", func(fileName, expectedModel string, expectedFeatures []string) {
+		model, features, ok := loadDomCapabilitiesFixture(fileName).HostModel()
+		Expect(ok).To(BeTrue())
+		Expect(model).To(Equal(expectedModel))
+		Expect(features).To(Equal(expectedFeatures))
+	},
+		Entry("on an Intel host", "domcapabilities_intel.xml", "Icelake-Server", []string{
+			"+ss", "+vmx", "+pdcm", "+hypervisor", "+tsc_adjust", "+arch-capabilities", "+invtsc", "-mpx", "-hle", "-rtm",
+		}),
+		Entry("on an AMD host", "domcapabilities_amd.xml", "EPYC-Milan", []string{
+			"+x2apic", "+hypervisor", "+svm", "+npt", "+nrip-save", "+invtsc", "-monitor",
+		}),
+		Entry("on a nested virtualization host", "domcapabilities_nested.xml", "Skylake-Client-IBRS", []string{
+			"+hypervisor", "+vmx", "+ssbd", "-invtsc", "-pdpe1gb",
+		}),
+	)
+
+	DescribeTable("should not resolve the host model", func(fileName string) {
+		_, _, ok := loadDomCapabilitiesFixture(fileName).HostModel()
+		Expect(ok).To(BeFalse())
+	},
+		Entry("when the host model has an empty name", "domcapabilities_empty_model.xml"),
+		Entry("when the cpu modes are absent", "domcapabilities_virgl.xml"),
+	)
+
+	DescribeTable("should parse the custom models with their usability", func(fileName string, models map[string]string) {
+		custom := getMode(loadDomCapabilitiesFixture(fileName), "custom")
+		Expect(getModelUsability(custom)).To(Equal(models))
+	},
+		Entry("on an Intel host", "domcapabilities_intel.xml", map[string]string{
+			"qemu64":                    "yes",
+			"Penryn":                    "yes",
+			"Nehalem":                   "yes",
+			"SandyBridge":               "yes",
+			"IvyBridge":                 "yes",
+			"Haswell-noTSX":             "yes",
+			"Haswell":                   "no",
+			"Skylake-Server-noTSX-IBRS": "yes",
+			"Icelake-Server-noTSX":      "yes",
+			"Icelake-Server":            "no",
+			"Opteron_G2":                "no",
+			"EPYC":                      "no",
+		}),
+		Entry("on an AMD host", "domcapabilities_amd.xml", map[string]string{
+			"qemu64":              "yes",
+			"Penryn":              "yes",
+			"Opteron_G2":          "yes",
+			"Opteron_G3":          "yes",
+			"Opteron_G4":          "no",
+			"EPYC":                "yes",
+			"EPYC-Rome":           "yes",
+			"EPYC-Milan":          "yes",
+			"Skylake-Client-IBRS": "no",
+			"Icelake-Client":      "no",
+		}),
+		Entry("on a nested virtualization host", "domcapabilities_nested.xml", map[string]string{
+			"qemu64":              "yes",
+			"kvm64":               "yes",
+			"Penryn":              "yes",
+			"Nehalem":             "yes",
+			"Skylake-Client-IBRS": "no",
+			"Skylake-Server":      "no",
+			"Opteron_G2":          "no",
+		}),
+	)
+
+	DescribeTable("should parse the SEV configuration", func(fileName string, expected SEVConfiguration) {
+		Expect(loadDomCapabilitiesFixture(fileName).SEV).To(Equal(expected))
+	},
+		Entry("on an Intel host", "domcapabilities_intel.xml", SEVConfiguration{Supported: "no"}),
+		Entry("on an AMD host", "domcapabilities_amd.xml", SEVConfiguration{
+			Supported:       "yes",
+			CBitPos:         51,
+			ReducedPhysBits: 1,
+			MaxGuests:       509,
+		}),
+		Entry("on a nested virtualization host", "domcapabilities_nested.xml", SEVConfiguration{}),
+	)
+
+	DescribeTable("should parse the device capabilities", func(fileName string, aioModes []string, virtioIOMMU bool) {
+		devices := loadDomCapabilitiesFixture(fileName).Devices
+		Expect(devices.Disk.Supported).To(Equal("yes"))
+		Expect(devices.Disk.AIOModes()).To(Equal(aioModes))
+		Expect(devices.IOMMU.SupportsVirtIO()).To(Equal(virtioIOMMU))
+	},
+		Entry("on an Intel host", "domcapabilities_intel.xml", []string{"native", "threads"}, true),
+		Entry("on an AMD host", "domcapabilities_amd.xml", []string{"native", "threads", "io_uring"}, true),
+		Entry("on a nested virtualization host", "domcapabilities_nested.xml", nil, false),
+	)
+
+	DescribeTable("should parse the GIC versions", func(fileName string, versions []string) {
+		Expect(loadDomCapabilitiesFixture(fileName).GIC.Versions()).To(Equal(versions))
+	},
+		Entry("on an ARM host", "domcapabilities_arm64.xml", []string{"2", "3"}),
+		Entry("on an Intel host", "domcapabilities_intel.xml", nil),
+		Entry("when the gic feature is absent", "domcapabilities_nosev.xml", nil),
+	)
+
+	It("should parse the arm64 capabilities", func() {
+		domCapabilities := loadDomCapabilitiesFixture("domcapabilities_arm64.xml")
+
+		Expect(getMode(domCapabilities, "host-model").Supported).To(Equal("no"))
+		Expect(getModelUsability(getMode(domCapabilities, "custom"))).To(Equal(map[string]string{
+			"cortex-a57":  "unknown",
+			"cortex-a72":  "unknown",
+			"neoverse-n1": "unknown",
+		}))
+		Expect(domCapabilities.Devices.IOMMU.SupportsVirtIO()).To(BeTrue())
+	})
+
+	It("should split the migratable features", func() {
+		data, err := os.ReadFile(filepath.Join("testdata", "cpu_model_migratable.xml"))
+		Expect(err).ToNot(HaveOccurred())
+		model := FeatureModel{}
+		Expect(xml.Unmarshal(data, &model)).To(Succeed())
+
+		Expect(model.Model.Features).To(HaveLen(5))
+		Expect(model.Model.MigratableFeatures()).To(Equal([]Feature{
+			{Name: "apic"},
+			{Name: "clflush"},
+			{Name: "vmx", Migratable: "yes"},
+		}))
+	})
+
+	It("should only consider the migratable host features as migratable", func() {
+		volumePath := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(volumePath, supportedFeaturesXml), []byte(`<cpu mode='custom' match='exact'>
+    <feature policy='require' name='vmx'/>
+    <feature policy='require' name='invtsc' migratable='no'/>
+    <feature policy='disable' name='mpx'/>
+</cpu>`), 0644)).To(Succeed())
+
+		n := &NodeLabeller{logger: log.DefaultLogger(), volumePath: volumePath}
+		Expect(n.loadHostSupportedFeatures()).To(Succeed())
+		Expect(n.cpuInfo.hostFeatures).To(Equal(cpuFeatures{"vmx": true, "invtsc": true}))
+		Expect(n.cpuInfo.migratableHostFeatures).To(Equal(cpuFeatures{"vmx": true}))
+	})
+
+	It("should return the required features of a model", func() {
+		data, err := os.ReadFile(filepath.Join("testdata", "cpu_model_policy.xml"))
+		Expect(err).ToNot(HaveOccurred())
+		model := FeatureModel{}
+		Expect(xml.Unmarshal(data, &model)).To(Succeed())
+
+		Expect(model.Model.Features).To(HaveLen(6))
+		Expect(model.Model.RequiredFeatures()).To(Equal(cpuFeatures{
+			"apic":    true,
+			"clflush": true,
+			"sse4.2":  true,
+			"vmx":     true,
+		}))
+	})
+
+	Context("checking the required features", func() {
+		info := cpuInfo{
+			usableModels: map[string]cpuFeatures{
+				"Penryn": {"apic": true, "sse4.1": true},
+			},
+			hostFeatures: cpuFeatures{"vmx": true, "xsaves": true},
+		}
+
+		DescribeTable("should report whether the required features are satisfiable", func(model string, required []string, supported bool, missing []string) {
+			isSupported, missingFeatures := info.SupportsFeatures(model, required)
+			Expect(isSupported).To(Equal(supported))
+			Expect(missingFeatures).To(Equal(missing))
+		},
+			Entry("with features of the model and the host", "Penryn", []string{"apic", "vmx"}, true, []string{}),
+			Entry("without required features", "Penryn", nil, true, []string{}),
+			Entry("with a missing feature", "Penryn", []string{"apic", "svm"}, false, []string{"svm"}),
+			Entry("with differently cased features", "Penryn", []string{"APIC", "Sse4.1", "VMX"}, true, []string{}),
+			Entry("with +/- prefixed features", "Penryn", []string{"+apic", "-svm", "+pdpe1gb"}, false, []string{"pdpe1gb"}),
+			Entry("with an unknown model", "unknown", []string{"apic", "vmx"}, false, []string{"apic"}),
+		)
+	})
+
+	DescribeTable("should compute the features common to all usable models", func(usableModels map[string]cpuFeatures, expected []string) {
+		Expect(cpuInfo{usableModels: usableModels}.CommonFeatures()).To(Equal(expected))
+	},
+		Entry("without usable models", nil, []string{}),
+		Entry("with a single usable model", map[string]cpuFeatures{
+			"Penryn": {"sse4.1": true, "apic": true, "cx16": true},
+		}, []string{"apic", "cx16", "sse4.1"}),
+		Entry("with several usable models", map[string]cpuFeatures{
+			"Penryn":              {"sse4.1": true, "apic": true, "cx16": true},
+			"Nehalem":             {"sse4.2": true, "sse4.1": true, "apic": true, "cx16": true},
+			"Skylake-Client-IBRS": {"sse4.1": true, "apic": true, "avx2": true},
+		}, []string{"apic", "sse4.1"}),
+		Entry("with a usable model without features", map[string]cpuFeatures{
+			"Penryn": {"sse4.1": true, "apic": true},
+			"qemu64": {},
+		}, []string{}),
+	)
+
+	It("should sort the supported features", func() {
+		items := []string{"Skylake-Client-IBRS", "Penryn", "Nehalem", "Conroe"}
+		features := newSupportedFeatures(items)
+		Expect(features.Items()).To(Equal([]string{"Conroe", "Nehalem", "Penryn", "Skylake-Client-IBRS"}))
+		Expect(items).To(Equal([]string{"Skylake-Client-IBRS", "Penryn", "Nehalem", "Conroe"}), "the input should not be modified")
+
+		features.Items()[0] = "486"
+		Expect(features.Items()).To(Equal([]string{"Conroe", "Nehalem", "Penryn", "Skylake-Client-IBRS"}), "the accessor should return a copy")
+	})
+
+	DescribeTable("should count the usable cpu models", func(modelUsability map[string]bool, expectedUsable, expectedTotal int) {
+		usable, total := cpuInfo{modelUsability: modelUsability}.ModelUsability()
+		Expect(usable).To(Equal(expectedUsable))
+		Expect(total).To(Equal(expectedTotal))
+	},
+		Entry("without models", nil, 0, 0),
+		Entry("with usable and unusable models", map[string]bool{
+			"Penryn":              true,
+			"Nehalem":             true,
+			"Skylake-Client-IBRS": false,
+			"EPYC":                false,
+			"Opteron_G2":          false,
+		}, 2, 5),
+		Entry("with unusable models only", map[string]bool{"EPYC": false, "Opteron_G2": false}, 0, 2),
+	)
+
+	DescribeTable("should list the models which became unusable", func(prev, current map[string]bool, expected []string) {
+		Expect(cpuInfo{modelUsability: current}.NewlyUnusable(cpuInfo{modelUsability: prev})).To(Equal(expected))
+	},
+		Entry("without a previous snapshot", nil, map[string]bool{"Penryn": true}, []string{}),
+		Entry("with unchanged models", map[string]bool{"Penryn": true, "EPYC": false}, map[string]bool{"Penryn": true, "EPYC": false}, []string{}),
+		Entry("with a model flipping from usable to unusable",
+			map[string]bool{"Penryn": true, "Skylake-Client-IBRS": true, "EPYC": false},
+			map[string]bool{"Penryn": true, "Skylake-Client-IBRS": false, "EPYC": false},
+			[]string{"Skylake-Client-IBRS"}),
+		Entry("with a model which libvirt does not report anymore",
+			map[string]bool{"Penryn": true, "Nehalem": true},
+			map[string]bool{"Penryn": true},
+			[]string{"Nehalem"}),
+		Entry("with a model becoming usable", map[string]bool{"EPYC": false}, map[string]bool{"EPYC": true}, []string{}),
+	)
+
+	Context("feature delta", func() {
+		var info cpuInfo
+
+		BeforeEach(func() {
+			n := &NodeLabeller{logger: log.DefaultLogger(), volumePath: "testdata"}
+			Expect(n.loadCPUInfo()).To(Succeed())
+			info = n.cpuInfo
+			info.usableModels["Haswell-noTSX"] = cpuFeatures{"apic": true, "avx2": true, "clflush": true, "x2apic": true}
+		})
+
+		DescribeTable("should list the features the target model adds and removes", func(baseModel, targetModel string, expectedAdded, expectedRemoved []string) {
+			added, removed, err := info.FeatureDelta(baseModel, targetModel)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(added).To(Equal(expectedAdded))
+			Expect(removed).To(Equal(expectedRemoved))
+		},
+			Entry("with the same model", "Penryn", "Penryn", []string{}, []string{}),
+			Entry("with a subset of the target model", "Penryn", "Haswell-noTSX", []string{"avx2", "x2apic"}, []string{}),
+			Entry("with a superset of the target model", "Haswell-noTSX", "Penryn", []string{}, []string{"avx2", "x2apic"}),
+			Entry("with disjoint models", "Opteron_G2", "Penryn", []string{"apic", "clflush"}, []string{"svm"}),
+		)
+
+		It("should list the features of overlapping models in order", func() {
+			added, removed, err := info.FeatureDelta("Haswell-noTSX", "Skylake-Client-IBRS")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(added).To(ContainElements("aes", "avx", "hle", "xsave"))
+			Expect(added).ToNot(ContainElements("apic", "avx2", "clflush", "x2apic"))
+			Expect(sort.StringsAreSorted(added)).To(BeTrue())
+			Expect(removed).To(BeEmpty())
+		})
+
+		DescribeTable("should fail with an unknown model", func(baseModel, targetModel string) {
+			_, _, err := info.FeatureDelta(baseModel, targetModel)
+			Expect(err).To(MatchError("unknown cpu model EPYC"))
+		},
+			Entry("as base model", "EPYC", "Penryn"),
+			Entry("as target model", "Penryn", "EPYC"),
+		)
+	})
+
+	Context("migration compatibility", func() {
+		newCPUInfo := func(modelUsability map[string]bool, hostFeatures ...string) cpuInfo {
+			c := cpuInfo{
+				usableModels: map[string]cpuFeatures{
+					"Penryn":  {"apic": true, "clflush": true},
+					"Nehalem": {"apic": true, "clflush": true, "popcnt": true},
+				},
+				hostFeatures:           cpuFeatures{},
+				migratableHostFeatures: cpuFeatures{},
+				modelUsability:         modelUsability,
+			}
+			for _, feature := range hostFeatures {
+				c.hostFeatures[feature] = true
+				c.migratableHostFeatures[feature] = true
+			}
+			return c
+		}
+
+		It("should allow the migration between hosts with the same capabilities", func() {
+			src := newCPUInfo(map[string]bool{"Penryn": true, "Nehalem": true}, "vmx", "ssse3")
+			dst := newCPUInfo(map[string]bool{"Nehalem": true, "Penryn": true}, "ssse3", "vmx", "aes")
+			compatible, reasons := MigrationCompatible(src, dst, "Penryn", []string{"+vmx", "ssse3", "-aes"})
+			Expect(compatible).To(BeTrue())
+			Expect(reasons).To(BeEmpty())
+		})
+
+		It("should block the migration to a host missing a single feature", func() {
+			src := newCPUInfo(map[string]bool{"Penryn": true}, "vmx", "ssse3")
+			dst := newCPUInfo(map[string]bool{"Penryn": true}, "vmx")
+			compatible, reasons := MigrationCompatible(src, dst, "Penryn", []string{"vmx", "ssse3"})
+			Expect(compatible).To(BeFalse())
+			Expect(reasons).To(Equal([]string{"cpu feature ssse3 is not supported on the destination"}))
+		})
+
+		It("should block the migration to a host which can not use the model", func() {
+			src := newCPUInfo(map[string]bool{"Nehalem": true}, "vmx")
+			dst := newCPUInfo(map[string]bool{"Nehalem": false}, "vmx")
+			compatible, reasons := MigrationCompatible(src, dst, "Nehalem", []string{"vmx"})
+			Expect(compatible).To(BeFalse())
+			Expect(reasons).To(Equal([]string{"cpu model Nehalem is not usable on the destination"}))
+		})
+
+		It("should block the migration of a feature the source can not live migrate", func() {
+			src := newCPUInfo(map[string]bool{"Penryn": true}, "vmx", "invtsc")
+			delete(src.migratableHostFeatures, "invtsc")
+			dst := newCPUInfo(map[string]bool{"Penryn": true}, "vmx", "invtsc")
+			compatible, reasons := MigrationCompatible(src, dst, "Penryn", []string{"vmx", "+invtsc"})
+			Expect(compatible).To(BeFalse())
+			Expect(reasons).To(Equal([]string{"cpu feature invtsc can not be live migrated from the source"}))
+		})
+
+		It("should ignore the features the source does not support", func() {
+			src := newCPUInfo(map[string]bool{"Penryn": true}, "vmx")
+			dst := newCPUInfo(map[string]bool{"Penryn": true}, "vmx")
+			compatible, reasons := MigrationCompatible(src, dst, "Penryn", []string{"vmx", "avx512f"})
+			Expect(compatible).To(BeTrue())
+			Expect(reasons).To(BeEmpty())
+		})
+	})
+
+	Context("recommended group model", func() {
+		models := map[string]cpuFeatures{
+			"Penryn":     {"apic": true, "clflush": true},
+			"Nehalem":    {"apic": true, "clflush": true, "popcnt": true},
+			"Westmere":   {"apic": true, "clflush": true, "popcnt": true, "aes": true},
+			"Haswell":    {"apic": true, "clflush": true, "popcnt": true, "aes": true, "avx2": true},
+			"Opteron_G3": {"apic": true, "clflush": true, "popcnt": true},
+		}
+		newCPUInfo := func(usable []string, hostFeatures ...string) cpuInfo {
+			c := cpuInfo{
+				usableModels:           models,
+				hostFeatures:           cpuFeatures{},
+				migratableHostFeatures: cpuFeatures{},
+				modelUsability:         map[string]bool{},
+			}
+			for model := range models {
+				c.modelUsability[model] = false
+			}
+			for _, model := range usable {
+				c.modelUsability[model] = true
+			}
+			for _, feature := range hostFeatures {
+				c.hostFeatures[feature] = true
+				c.migratableHostFeatures[feature] = true
+			}
+			return c
+		}
+
+		It("should recommend the richest model and the features shared by a heterogeneous group", func() {
+			infos := []cpuInfo{
+				newCPUInfo([]string{"Penryn", "Nehalem", "Westmere", "Haswell"}, "apic", "popcnt", "aes", "avx2", "vmx", "ssse3", "pdpe1gb"),
+				newCPUInfo([]string{"Penryn", "Nehalem", "Westmere"}, "apic", "popcnt", "aes", "vmx", "ssse3"),
+				newCPUInfo([]string{"Penryn", "Nehalem", "Opteron_G3"}, "apic", "popcnt", "ssse3", "svm"),
+			}
+			model, features, ok := RecommendedGroupModel(infos)
+			Expect(ok).To(BeTrue())
+			Expect(model).To(Equal("Nehalem"))
+			Expect(features).To(Equal([]string{"ssse3"}))
+		})
+
+		It("should not recommend features which can not be live migrated", func() {
+			infos := []cpuInfo{
+				newCPUInfo([]string{"Penryn", "Nehalem"}, "ssse3", "invtsc"),
+				newCPUInfo([]string{"Penryn", "Nehalem"}, "ssse3", "invtsc"),
+			}
+			for _, info := range infos {
+				delete(info.migratableHostFeatures, "invtsc")
+			}
+			model, features, ok := RecommendedGroupModel(infos)
+			Expect(ok).To(BeTrue())
+			Expect(model).To(Equal("Nehalem"))
+			Expect(features).To(Equal([]string{"ssse3"}))
+		})
+
+		It("should break ties between equally rich models by name", func() {
+			infos := []cpuInfo{
+				newCPUInfo([]string{"Penryn", "Nehalem", "Opteron_G3"}),
+				newCPUInfo([]string{"Opteron_G3", "Nehalem"}),
+			}
+			model, features, ok := RecommendedGroupModel(infos)
+			Expect(ok).To(BeTrue())
+			Expect(model).To(Equal("Nehalem"))
+			Expect(features).To(BeEmpty())
+		})
+
+		DescribeTable("should not recommend a model", func(infos []cpuInfo) {
+			_, _, ok := RecommendedGroupModel(infos)
+			Expect(ok).To(BeFalse())
+		},
+			Entry("for an empty group", nil),
+			Entry("when the hosts share no usable model", []cpuInfo{
+				newCPUInfo([]string{"Haswell"}),
+				newCPUInfo([]string{"Opteron_G3"}),
+			}),
+		)
+	})
+
+	It("should report the models which became unusable since the previous reconcile", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), cpuInfo: cpuInfo{modelUsability: map[string]bool{"Penryn": true, "Nehalem": true}}}
+		Expect(n.checkNewlyUnusableModels()).To(BeEmpty())
+
+		n.cpuInfo = cpuInfo{modelUsability: map[string]bool{"Penryn": true, "Nehalem": false}}
+		Expect(n.checkNewlyUnusableModels()).To(Equal([]string{"Nehalem"}))
+		Expect(n.checkNewlyUnusableModels()).To(BeEmpty())
+	})
+
+	It("should annotate the node with the cpu model usability", func() {
+		n := &NodeLabeller{cpuInfo: cpuInfo{modelUsability: map[string]bool{"Penryn": true, "EPYC": false}}}
+		node := &k8sv1.Node{}
+		n.setModelUsabilityAnnotation(node)
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.CPUModelUsabilityAnnotation, "1/2"))
+
+		n.cpuInfo.modelUsability = nil
+		n.setModelUsabilityAnnotation(node)
+		Expect(node.Annotations).ToNot(HaveKey(kubevirtv1.CPUModelUsabilityAnnotation))
+	})
+
+	DescribeTable("should detect cpu hotplug support", func(domCapabilities HostDomCapabilities, supported bool) {
+		Expect(domCapabilities.SupportsCPUHotplug()).To(Equal(supported))
+	},
+		Entry("on a kvm x86_64 host", HostDomCapabilities{Domain: "kvm", Arch: "x86_64", VCPU: VCPU{Max: 710}}, true),
+		Entry("on a kvm s390x host", HostDomCapabilities{Domain: "kvm", Arch: "s390x", VCPU: VCPU{Max: 248}}, true),
+		Entry("on a kvm aarch64 host", HostDomCapabilities{Domain: "kvm", Arch: "aarch64", VCPU: VCPU{Max: 512}}, false),
+		Entry("on an emulated host", HostDomCapabilities{Domain: "qemu", Arch: "x86_64", VCPU: VCPU{Max: 255}}, false),
+		Entry("with a single vCPU per domain", HostDomCapabilities{Domain: "kvm", Arch: "x86_64", VCPU: VCPU{Max: 1}}, false),
+	)
+
+	Context("machine types", func() {
+		domCapabilities := HostDomCapabilities{
+			Machines: []string{"pc-q35-7.2", "pc-q35-8.0", "pc-q35-rhel9.2.0", "pc-q35-10.1", "q35", "pc-i440fx-8.0", "virt-7.2", "virt-8.0"},
+		}
+
+		DescribeTable("should resolve the machine type", func(name, expectedMachine string, expectedOk bool) {
+			resolved, ok := domCapabilities.SupportsMachine(name)
+			Expect(ok).To(Equal(expectedOk))
+			Expect(resolved).To(Equal(expectedMachine))
+		},
+			Entry("with an explicit version", "pc-q35-8.0", "pc-q35-8.0", true),
+			Entry("with an explicit vendor version", "pc-q35-rhel9.2.0", "pc-q35-rhel9.2.0", true),
+			Entry("with an unsupported explicit version", "pc-q35-6.0", "", false),
+			Entry("with an unversioned machine type listed as is", "q35", "q35", true),
+			Entry("with an unversioned machine type", "virt", "virt-8.0", true),
+			Entry("with an unversioned alias", "pc", "pc-i440fx-8.0", true),
+			Entry("with an unsupported machine type", "microvm", "", false),
+		)
+
+		It("should resolve an unversioned machine type to the highest version", func() {
+			resolved, ok := HostDomCapabilities{Machines: []string{"pc-q35-7.2", "pc-q35-10.1", "pc-q35-8.0"}}.SupportsMachine("q35")
+			Expect(ok).To(BeTrue())
+			Expect(resolved).To(Equal("pc-q35-10.1"))
+		})
+
+		It("should parse the machine type of the domain capabilities", func() {
+			Expect(loadDomCapabilitiesFixture("domcapabilities_virgl.xml").Machines).To(Equal([]string{"pc-q35-8.0"}))
+		})
+	})
+})
@@ -23,9 +23,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
+	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/client-go/tools/record"
@@ -54,6 +58,7 @@ var nodeLabellerLabels = []string{
 	kubevirtv1.CPUFeatureLabel,
 	kubevirtv1.CPUModelLabel,
 	kubevirtv1.SupportedHostModelMigrationCPU,
+	kubevirtv1.CPUModelVendorLabel,
 	kubevirtv1.CPUTimerLabel,
 	kubevirtv1.HypervLabel,
 	kubevirtv1.RealtimeLabel,
@@ -62,6 +67,53 @@ var nodeLabellerLabels = []string{
 	kubevirtv1.HostModelCPULabel,
 	kubevirtv1.HostModelRequiredFeaturesLabel,
 	kubevirtv1.NodeHostModelIsObsoleteLabel,
+	kubevirtv1.DiskAIOLabel,
+	kubevirtv1.NUMATuningLabel,
+	kubevirtv1.VirtIOIOMMULabel,
+	kubevirtv1.CPUSocketsLabel,
+	kubevirtv1.CPUCoresPerSocketLabel,
+	kubevirtv1.CPUThreadsPerCoreLabel,
+	kubevirtv1.MemoryHotUnplugLabel,
+	kubevirtv1.GICVersionLabel,
+	kubevirtv1.PMEMAvailableLabel,
+	kubevirtv1.PMEMCapacityLabel,
+	kubevirtv1.VirglLabel,
+	kubevirtv1.CPUMicrocodeLabel,
+	kubevirtv1.CPUModelCountLabel,
+	kubevirtv1.WatchdogActionLabel,
+	kubevirtv1.NodeLabellerSchedulableLabel,
+	kubevirtv1.CPUL1CacheLabel,
+	kubevirtv1.CPUL2CacheLabel,
+	kubevirtv1.CPUL3CacheLabel,
+	kubevirtv1.RealtimeCapableLabel,
+	kubevirtv1.NoUsableCPUModelLabel,
+	kubevirtv1.CPUFeatureFamilyLabel,
+	kubevirtv1.IOMMULabel,
+	kubevirtv1.Hugepages2MiLabel,
+	kubevirtv1.Hugepages1GiLabel,
+	kubevirtv1.TPMLabel,
+	kubevirtv1.CPUInfoFlagLabel,
+	kubevirtv1.KVMHintDedicatedLabel,
+	kubevirtv1.UEFILabel,
+	kubevirtv1.SecureBootLabel,
+	kubevirtv1.CPUGovernorLabel,
+	kubevirtv1.VirtiofsLabel,
+	kubevirtv1.DeviceCountLabel,
+	kubevirtv1.CPUVendorIDSpoofLabel,
+	kubevirtv1.MaxVCPULabel,
+	kubevirtv1.NUMALabel,
+	kubevirtv1.NUMANodesLabel,
+	kubevirtv1.CPUVulnMitigatedLabel,
+	kubevirtv1.CPUHotplugLabel,
+	kubevirtv1.RDTCATLabel,
+	kubevirtv1.RDTMBALabel,
+}
+
+// cacheLevelLabels maps the cache levels to their size labels
+var cacheLevelLabels = map[int]string{
+	1: kubevirtv1.CPUL1CacheLabel,
+	2: kubevirtv1.CPUL2CacheLabel,
+	3: kubevirtv1.CPUL3CacheLabel,
 }
 
 // NodeLabeller struct holds information needed to run node-labeller
@@ -83,13 +135,63 @@ type NodeLabeller struct {
 	capabilities            *api.Capabilities
 	hostCPUModel            hostCPUModel
 	SEV                     SEVConfiguration
+	diskAIOModes            []string
+	watchdogActions         []string
+	gicVersions             []string
+	maxVCPUs                int
+	cpuHotplugSupported     bool
+	virtioIOMMUSupported    bool
+	memoryHotUnplug         bool
+	virglSupported          bool
+	vendorIDSpoofSupported  bool
+	changeHistoryLength     int
+	labelPrefix             string
+	categories              map[LabelCategory]bool
+	dryRun                  bool
+	labelBudget             int
+	trigger                 <-chan struct{}
+	lastAppliedLock         sync.Mutex
+	lastAppliedLabels       map[string]string
+	hostFS                  fs.FS
+	pmemCapacity            int64
+	microcodeRevision       string
+	kvmAvailable            bool
+	deniedFeatures          map[string]bool
+	modelProbe              ModelProbe
+	versionSource           versionSource
+	libvirtVersion          string
+	qemuVersion             string
+	featureFamilies         FeatureFamilies
+	tpmProbe                tpmProbe
+	tpmSupported            bool
+	cpuInfoFlagsEnabled     bool
+	cpuInfoFlagsReader      cpuInfoFlagsReader
+	cpuInfoFlags            []string
+	firmwareProbe           firmwareProbe
+	uefiSupported           bool
+	secureBootSupported     bool
+	cpuGovernorReader       cpuGovernorReader
+	cpuInfoSnapshot         *cpuInfo
+	binaryProbe             binaryProbe
+	virtiofsSupported       bool
+	capabilitiesProbe       capabilitiesProbe
+	deviceSource            deviceSource
+	numaSource              numaSource
+	cpuVulnerabilityReader  cpuVulnerabilityReader
+	cpuVulnerabilities      map[string]string
+	resctrlReader           resctrlReader
 }
 
-func NewNodeLabeller(clusterConfig *virtconfig.ClusterConfig, clientset kubecli.KubevirtClient, host, namespace string, recorder record.EventRecorder) (*NodeLabeller, error) {
-	return newNodeLabeller(clusterConfig, clientset, host, namespace, nodeLabellerVolumePath, recorder)
+func NewNodeLabeller(clusterConfig *virtconfig.ClusterConfig, clientset kubecli.KubevirtClient, host, namespace string, recorder record.EventRecorder, opts ...Option) (*NodeLabeller, error) {
+	return newNodeLabeller(clusterConfig, clientset, host, namespace, nodeLabellerVolumePath, recorder, opts...)
 
 }
-func newNodeLabeller(clusterConfig *virtconfig.ClusterConfig, clientset kubecli.KubevirtClient, host, namespace string, volumePath string, recorder record.EventRecorder) (*NodeLabeller, error) {
+func newNodeLabeller(clusterConfig *virtconfig.ClusterConfig, clientset kubecli.KubevirtClient, host, namespace string, volumePath string, recorder record.EventRecorder, opts ...Option) (*NodeLabeller, error) {
+	o, err := applyOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	n := &NodeLabeller{
 		recorder:                recorder,
 		clientset:               clientset,
@@ -99,14 +201,54 @@ func newNodeLabeller(clusterConfig *virtconfig.ClusterConfig, clientset kubecli.
 		clusterConfig:           clusterConfig,
 		queue:                   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virt-handler-node-labeller"),
 		volumePath:              volumePath,
+		hostFS:                  os.DirFS("/"),
 		domCapabilitiesFileName: "virsh_domcapabilities.xml",
 		hostCPUModel:            hostCPUModel{requiredFeatures: make(map[string]bool, 0)},
-	}
-
-	err := n.loadAll()
+		changeHistoryLength:     defaultChangeHistoryLength,
+		labelPrefix:             o.prefix,
+		categories:              o.categories,
+		dryRun:                  o.dryRun,
+		labelBudget:             o.labelBudget,
+		trigger:                 o.trigger,
+		deniedFeatures:          o.deniedFeatures,
+		modelProbe:              o.modelProbe,
+		featureFamilies:         o.featureFamilies,
+		cpuInfoFlagsEnabled:     o.cpuInfoFlags,
+	}
+	n.versionSource = func() (string, string, error) {
+		return readVirshVersion(n.volumePath)
+	}
+	n.tpmProbe = n.hasSwtpm
+	n.firmwareProbe = n.hasFirmwareFile
+	n.binaryProbe = n.hasExecutable
+	n.capabilitiesProbe = n.hasDomCapabilities
+	n.deviceSource = n.permittedDeviceCounts
+	n.numaSource = n.hostNUMANodes
+	n.cpuGovernorReader = func() ([]string, error) {
+		return readCPUGovernors(n.hostFS)
+	}
+	n.cpuInfoFlagsReader = func() ([]string, error) {
+		return readCPUInfoFlags(n.hostFS)
+	}
+	n.cpuVulnerabilityReader = func() (map[string]string, error) {
+		return readCPUVulnerabilities(n.hostFS)
+	}
+	n.resctrlReader = func() ([]string, error) {
+		return readResctrlResources(n.hostFS)
+	}
+	if o.changeHistoryLength != nil {
+		n.changeHistoryLength = *o.changeHistoryLength
+	} else if o.dryRun {
+		n.changeHistoryLength = 0
+	}
+
+	err = n.loadAll()
 	if err != nil {
+		// without the host capabilities the node can never be reconciled
+		reportReconcileResult(reconcileResultParseError)
 		return n, err
 	}
+	n.warnUnmatchedObsoleteModels()
 	return n, nil
 }
 
@@ -122,6 +264,7 @@ func (n *NodeLabeller) Run(threadiness int, stop chan struct{}) {
 
 	interval := 3 * time.Minute
 	go wait.JitterUntil(func() { n.queue.Add(n.host) }, interval, 1.2, true, stop)
+	go n.watchTrigger(stop)
 
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(n.runWorker, time.Second, stop)
@@ -129,6 +272,24 @@ func (n *NodeLabeller) Run(threadiness int, stop chan struct{}) {
 	<-stop
 }
 
+// watchTrigger queues the node whenever the trigger is signaled, until stop is closed
+func (n *NodeLabeller) watchTrigger(stop <-chan struct{}) {
+	if n.trigger == nil {
+		return
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case _, ok := <-n.trigger:
+			if !ok {
+				return
+			}
+			n.queue.Add(n.host)
+		}
+	}
+}
+
 func (n *NodeLabeller) runWorker() {
 	for n.execute() {
 	}
@@ -182,35 +343,215 @@ func (n *NodeLabeller) loadAll() error {
 	}
 
 	n.loadHypervFeatures()
+	n.loadPersistentMemory()
+	n.loadVersions()
+	n.loadTPMSupport()
+	n.loadCPUInfoFlags()
+	n.loadFirmware()
+	n.loadVirtiofsSupport()
+	n.loadCPUVulnerabilities()
 
 	return nil
 }
 
 func (n *NodeLabeller) run() error {
-	obsoleteCPUsx86 := n.clusterConfig.GetObsoleteCPUModels()
-	cpuModels := n.getSupportedCpuModels(obsoleteCPUsx86)
+	_, _, err := n.ReconcileAndReport(n.host)
+	return err
+}
+
+// reconciliation is the outcome of a labelling pass computed for a node
+type reconciliation struct {
+	// node is a copy of the node carrying the labels and annotations of the pass
+	node *v1.Node
+	// labels are the labeller labels computed for the node, nil if the node was not labelled
+	labels map[string]string
+	// missingCapabilities are the capabilities of the minimal cluster CPU model the node lacks
+	missingCapabilities []string
+	// applied reports whether the node already carries the labels and annotations, so that it does not need a patch
+	applied bool
+}
+
+// Reconcile computes the labels and annotations a labelling pass would apply on the given node
+// from the loaded host capabilities, without modifying the node or reaching out to the cluster
+func (n *NodeLabeller) Reconcile(ctx context.Context, node *v1.Node) (labels, annotations map[string]string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	result := n.reconcile(node, nil)
+	return result.node.Labels, result.node.Annotations, nil
+}
+
+// reconcile computes the labelling pass for the node, passErr is the error the pass ran into while
+// refreshing the host capabilities, which keeps the node from being labelled as schedulable
+func (n *NodeLabeller) reconcile(originalNode *v1.Node, passErr error) reconciliation {
+	node := originalNode.DeepCopy()
+	if skipNodeLabelling(node) {
+		return reconciliation{node: node}
+	}
+	if capabilitiesErr := n.capabilitiesProbe(); capabilitiesErr != nil {
+		n.removeStaleLabels(node, capabilitiesErr)
+		return reconciliation{node: node}
+	}
+
+	cpuModelPolicy := n.getCPUModelPolicy()
+	obsoleteCPUsx86 := cpuModelPolicy.ObsoleteCPUModels
+	cpuModels, rejectedModels := n.probeCPUModels(n.getSupportedCpuModels(cpuModelPolicy))
 	cpuFeatures := n.getSupportedCpuFeatures()
 	hostCPUModel := n.GetHostCpuModel()
 
-	originalNode, err := n.clientset.CoreV1().Nodes().Get(context.Background(), n.host, metav1.GetOptions{})
+	//prepare new labels
+	newLabels := n.prepareLabels(node, cpuModels, cpuFeatures, hostCPUModel, obsoleteCPUsx86, passErr)
+	missingCapabilities := n.missingRequiredCapabilities(newLabels)
+	newLabels = n.finalizeLabels(newLabels)
+	var originalNames map[string]string
+	newLabels, originalNames = sanitizeLabels(newLabels)
+	drifted := n.repairLabelDrift(node, newLabels)
+	applied := !drifted && !hasStaleLabels(node) && n.isAppliedOnNode(node, newLabels)
+
+	//replace the old labeller labels with the new ones, leaving all other labels untouched
+	node.Labels = mergeLabels(node.Labels, newLabels, n.isLabellerLabel)
+	n.removeDeprecatedAnnotations(node)
+	delete(node.Annotations, kubevirtv1.CPULabelsStaleAnnotation)
+	setOriginalNamesAnnotation(node, originalNames)
+	n.setModelUsabilityAnnotation(node)
+	n.setCPUFingerprintAnnotation(node)
+	setRejectedModelsAnnotation(node, rejectedModels)
+	n.setVersionAnnotations(node)
+	n.setCPUVulnerabilityAnnotations(node)
+	n.setMigrationSensitiveFeaturesAnnotation(node, cpuFeatures)
+	// the annotations of the labeller are repaired as well, e.g. when they were edited or removed on the node
+	applied = applied && equality.Semantic.DeepEqual(originalNode.Annotations, node.Annotations)
+
+	return reconciliation{
+		node:                node,
+		labels:              newLabels,
+		missingCapabilities: missingCapabilities,
+		applied:             applied,
+	}
+}
+
+// ReconcileAndReport runs a single labelling pass on the given node and reports
+// which labels were added (or had their value changed) and which were removed
+func (n *NodeLabeller) ReconcileAndReport(nodeName string) (added, removed []string, err error) {
+	passErr := n.reloadModelUsability()
+	if passErr != nil {
+		n.logger.Warningf("node-labeller could not reload the cpu model usability: %v", passErr)
+	} else {
+		n.checkNewlyUnusableModels()
+	}
+
+	originalNode, err := n.clientset.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
 	if err != nil {
-		return err
+		reportReconcileResult(reconcileResultApplyError)
+		return nil, nil, err
 	}
 
-	node := originalNode.DeepCopy()
+	result := n.reconcile(originalNode, passErr)
+	node, newLabels := result.node, result.labels
+	if newLabels != nil {
+		reportMissingRequiredCapabilities(result.missingCapabilities)
+	}
+	if result.applied {
+		reportReconcileResult(reconcileResultNoop)
+		return []string{}, []string{}, nil
+	}
 
-	if !skipNodeLabelling(node) {
-		//prepare new labels
-		newLabels := n.prepareLabels(node, cpuModels, cpuFeatures, hostCPUModel, obsoleteCPUsx86)
-		//remove old labeller labels
-		n.removeLabellerLabels(node)
-		//add new labels
-		n.addLabellerLabels(node, newLabels)
+	added, removed = diffLabels(originalNode.Labels, node.Labels)
+	recordLabelChanges(node, added, removed, n.changeHistoryLength, changeHistorySizeBudget)
+
+	if n.dryRun {
+		n.logger.Infof("node-labeller dry-run on node %s would add or update labels %v and remove labels %v", nodeName, added, removed)
+		reportReconcileResult(reconcileResultSuccess)
+		return added, removed, nil
 	}
 
 	err = n.patchNode(originalNode, node)
+	if err != nil {
+		reportReconcileResult(reconcileResultApplyError)
+		return nil, nil, err
+	}
+	reportReconcileResult(reconcileResultSuccess)
+	n.setLastAppliedLabels(newLabels)
+	reportCPUFeatures(n.labelledCPUFeatures(newLabels))
 
-	return err
+	return added, removed, nil
+}
+
+// LastAppliedLabels returns a copy of the labeller labels applied by the last successful labelling pass
+func (n *NodeLabeller) LastAppliedLabels() map[string]string {
+	n.lastAppliedLock.Lock()
+	defer n.lastAppliedLock.Unlock()
+
+	labels := make(map[string]string, len(n.lastAppliedLabels))
+	for key, value := range n.lastAppliedLabels {
+		labels[key] = value
+	}
+	return labels
+}
+
+func (n *NodeLabeller) setLastAppliedLabels(labels map[string]string) {
+	n.lastAppliedLock.Lock()
+	defer n.lastAppliedLock.Unlock()
+	n.lastAppliedLabels = labels
+}
+
+// labelledCPUFeatures returns the cpu features of the labels, taking the configured prefix into account
+func (n *NodeLabeller) labelledCPUFeatures(labels map[string]string) []string {
+	featureLabel := withPrefix(kubevirtv1.CPUFeatureLabel, n.labelPrefix)
+	features := []string{}
+	for key := range labels {
+		if feature := strings.TrimPrefix(key, featureLabel); feature != key {
+			features = append(features, feature)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// isAppliedOnNode reports whether the labels match the last applied labels and the labeller labels
+// of the node are still exactly the last applied ones, so that the node does not need a patch
+func (n *NodeLabeller) isAppliedOnNode(node *v1.Node, labels map[string]string) bool {
+	n.lastAppliedLock.Lock()
+	defer n.lastAppliedLock.Unlock()
+
+	if n.lastAppliedLabels == nil || !equality.Semantic.DeepEqual(labels, n.lastAppliedLabels) {
+		return false
+	}
+
+	for key, value := range labels {
+		if nodeValue, exists := node.Labels[key]; !exists || nodeValue != value {
+			return false
+		}
+	}
+	for key := range node.Labels {
+		if !n.isLabellerLabel(key) {
+			continue
+		}
+		if _, exists := labels[key]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// diffLabels returns the sorted keys which are new or changed in updated and
+// the sorted keys which are missing from updated
+func diffLabels(original, updated map[string]string) (added, removed []string) {
+	added = []string{}
+	removed = []string{}
+	for key, value := range updated {
+		if originalValue, exists := original[key]; !exists || originalValue != value {
+			added = append(added, key)
+		}
+	}
+	for key := range original {
+		if _, exists := updated[key]; !exists {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
 }
 
 func skipNodeLabelling(node *v1.Node) bool {
@@ -261,79 +602,194 @@ func (n *NodeLabeller) patchNode(originalNode, node *v1.Node) error {
 }
 
 func (n *NodeLabeller) loadHypervFeatures() {
-	n.hypervFeatures.items = getCapLabels()
+	n.hypervFeatures = newSupportedFeatures(getCapLabels())
 }
 
 // prepareLabels converts cpu models, features, hyperv features to map[string]string format
 // e.g. "cpu-feature.node.kubevirt.io/Penryn": "true"
-func (n *NodeLabeller) prepareLabels(node *v1.Node, cpuModels []string, cpuFeatures cpuFeatures, hostCpuModel hostCPUModel, obsoleteCPUsx86 map[string]bool) map[string]string {
-	newLabels := make(map[string]string)
-	for key := range cpuFeatures {
-		newLabels[kubevirtv1.CPUFeatureLabel+key] = "true"
+func (n *NodeLabeller) prepareLabels(node *v1.Node, cpuModels []string, cpuFeatures cpuFeatures, hostCpuModel hostCPUModel, obsoleteCPUsx86 map[string]bool, passErr error) map[string]string {
+	return n.prepareNodeLabels(node, cpuModels, cpuFeatures, hostCpuModel, obsoleteCPUsx86, passErr).ToMap()
+}
+
+// prepareNodeLabels computes the typed node labels of the host
+func (n *NodeLabeller) prepareNodeLabels(node *v1.Node, cpuModels []string, features cpuFeatures, hostCpuModel hostCPUModel, obsoleteCPUsx86 map[string]bool, passErr error) NodeLabels {
+	labels := NodeLabels{
+		Features: sortedKeys(features),
 	}
+	n.addAMXLabels(&labels, features)
+	n.addFeatureFamilyLabels(&labels, features)
+	n.addCPUInfoFlagLabels(&labels, features)
+	n.addKVMHintDedicatedLabel(&labels, features)
 
+	supportedFeatures := make(cpuFeatures, len(labels.Features))
+	for _, feature := range labels.Features {
+		supportedFeatures[feature] = true
+	}
 	for _, value := range cpuModels {
-		if !n.shouldAddCPUModelLabel(value, &hostCpuModel, newLabels) {
+		if !n.shouldAddCPUModelLabel(value, &hostCpuModel, supportedFeatures) {
 			continue
 		}
-
-		newLabels[kubevirtv1.CPUModelLabel+value] = "true"
-		newLabels[kubevirtv1.SupportedHostModelMigrationCPU+value] = "true"
+		labels.Models = append(labels.Models, value)
 	}
+	labels.ModelCount = ModelRichnessScore(n.cpuInfo)
 
-	if _, hostModelObsolete := obsoleteCPUsx86[hostCpuModel.Name]; !hostModelObsolete {
-		newLabels[kubevirtv1.SupportedHostModelMigrationCPU+hostCpuModel.Name] = "true"
+	if _, hostModelObsolete := obsoleteCPUsx86[hostCpuModel.Name]; !hostModelObsolete && hostCpuModel.Name != "" {
+		labels.MigrationModels = append(labels.MigrationModels, hostCpuModel.Name)
 	}
 
-	for _, key := range n.hypervFeatures.items {
-		newLabels[kubevirtv1.HypervLabel+key] = "true"
-	}
+	labels.HypervFeatures = n.hypervFeatures.Items()
 
 	if c, err := n.capabilities.GetTSCCounter(); err == nil && c != nil {
-		newLabels[kubevirtv1.CPUTimerLabel+"tsc-frequency"] = fmt.Sprintf("%d", c.Frequency)
-		newLabels[kubevirtv1.CPUTimerLabel+"tsc-scalable"] = fmt.Sprintf("%v", c.Scaling)
+		labels.TSC = &TSCLabels{Frequency: c.Frequency, Scalable: bool(c.Scaling)}
 	} else if err != nil {
 		n.logger.Reason(err).Error("failed to get tsc cpu frequency, will continue without the tsc frequency label")
 	}
 
-	for feature := range hostCpuModel.requiredFeatures {
-		newLabels[kubevirtv1.HostModelRequiredFeaturesLabel+feature] = "true"
-	}
+	labels.HostModelRequiredFeatures = sortedKeys(hostCpuModel.requiredFeatures)
 	if _, obsolete := obsoleteCPUsx86[hostCpuModel.Name]; obsolete {
-		newLabels[kubevirtv1.NodeHostModelIsObsoleteLabel] = "true"
+		labels.HostModelObsolete = true
 		err := n.alertIfHostModelIsObsolete(node, hostCpuModel.Name, obsoleteCPUsx86)
 		if err != nil {
 			n.logger.Reason(err).Error(err.Error())
 		}
 	}
 
-	newLabels[kubevirtv1.CPUModelVendorLabel+n.cpuModelVendor] = "true"
-	newLabels[kubevirtv1.HostModelCPULabel+hostCpuModel.Name] = "true"
+	labels.Vendor = n.cpuModelVendor
+	labels.HostModel = hostCpuModel.Name
 
 	capable, err := isNodeRealtimeCapable()
 	if err != nil {
 		n.logger.Reason(err).Error("failed to identify if a node is capable of running realtime workloads")
 	}
-	if capable {
-		newLabels[kubevirtv1.RealtimeLabel] = ""
+	labels.Realtime = capable
+	n.addRealtimeCapableLabel(&labels)
+	n.addCPUGovernorLabel(&labels)
+	n.addRDTLabels(&labels)
+	n.addIOMMULabel(&labels)
+	n.addHugepagesLabels(&labels)
+	n.addTPMLabel(&labels)
+	n.addFirmwareLabels(&labels)
+	n.addVirtiofsLabel(&labels)
+	n.addDeviceCountLabels(&labels, node)
+	n.addNUMALabels(&labels)
+	n.addCPUVulnerabilityLabel(&labels)
+
+	labels.SEV = n.SEV.Supported == "yes"
+	labels.SEVES = n.SEV.SupportedES == "yes"
+
+	if virtconfig.IsARM64(runtime.GOARCH) {
+		labels.GICVersions = n.gicVersions
 	}
 
-	if n.SEV.Supported == "yes" {
-		newLabels[kubevirtv1.SEVLabel] = ""
+	labels.DiskAIOModes = n.diskAIOModes
+	labels.WatchdogActions = n.watchdogActions
+	labels.MaxVCPUs = n.maxVCPUs
+	labels.CPUHotplug = n.cpuHotplugSupported
+	labels.PMEMCapacity = n.pmemCapacity
+	labels.NUMATuning = n.capabilities.SupportsNUMAMemoryBinding()
+	labels.VirtIOIOMMU = n.virtioIOMMUSupported
+	labels.Virgl = n.virglSupported
+	labels.VendorIDSpoof = n.vendorIDSpoofSupported
+	labels.MemoryHotUnplug = n.memoryHotUnplug
+
+	if revision, ok := n.getMicrocodeRevision(); ok {
+		labels.Microcode = revision
 	}
 
-	if n.SEV.SupportedES == "yes" {
-		newLabels[kubevirtv1.SEVESLabel] = ""
+	if sockets, coresPerSocket, threadsPerCore, ok := n.capabilities.GetCPUTopology(); ok {
+		labels.Topology = &TopologyLabels{Sockets: sockets, CoresPerSocket: coresPerSocket, ThreadsPerCore: threadsPerCore}
 	}
 
-	return newLabels
+	for _, cache := range n.capabilities.GetCacheTopology() {
+		// split caches are labelled with the size of their data cache
+		if cache.Type == "instruction" {
+			continue
+		}
+		if labels.CacheSizesKiB == nil {
+			labels.CacheSizesKiB = make(map[int]uint64)
+		}
+		labels.CacheSizesKiB[cache.Level] = cache.SizeKiB
+	}
+
+	labels.NoUsableModel = len(cpuModels) == 0
+	labels.Schedulable = n.isSchedulable(cpuModels, passErr)
+
+	n.removeDeniedFeatures(&labels)
+
+	return labels
 }
 
-// addNodeLabels adds labels to node.
-func (n *NodeLabeller) addLabellerLabels(node *v1.Node, labels map[string]string) {
-	for key, value := range labels {
-		node.Labels[key] = value
+// removeDeniedFeatures removes the denied features from the cpu feature labels
+func (n *NodeLabeller) removeDeniedFeatures(labels *NodeLabels) {
+	if len(n.deniedFeatures) == 0 {
+		return
+	}
+
+	denied := make(map[string]bool)
+	filter := func(features []string) []string {
+		allowed := make([]string, 0, len(features))
+		for _, feature := range features {
+			if n.deniedFeatures[strings.ToLower(feature)] {
+				denied[feature] = true
+				continue
+			}
+			allowed = append(allowed, feature)
+		}
+		return allowed
+	}
+	labels.Features = filter(labels.Features)
+	labels.HostModelRequiredFeatures = filter(labels.HostModelRequiredFeatures)
+	labels.CPUInfoFlags = filter(labels.CPUInfoFlags)
+	if len(denied) == 0 {
+		return
 	}
+
+	features := make([]string, 0, len(denied))
+	for feature := range denied {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	n.logger.Infof("node-labeller does not advertise the denied cpu features %v", features)
+}
+
+// isSchedulable reports whether the node is fully capable of running VMs: KVM is available,
+// at least one cpu model is usable and the current labelling pass did not fail. The rollup label
+// is dropped by the labelling pass which finds one of these conditions no longer holding.
+func (n *NodeLabeller) isSchedulable(cpuModels []string, passErr error) bool {
+	if !n.kvmAvailable {
+		n.logger.V(4).Info("node-labeller omits the schedulable label, kvm is not available")
+		return false
+	}
+	if len(cpuModels) == 0 {
+		n.logger.V(4).Info("node-labeller omits the schedulable label, no cpu model is usable")
+		return false
+	}
+	if passErr != nil {
+		n.logger.V(4).Infof("node-labeller omits the schedulable label, the labelling pass failed: %v", passErr)
+		return false
+	}
+	return true
+}
+
+// missingRequiredCapabilities returns the capabilities required by the minimal cluster CPU model
+// which are not part of the given node labels, e.g. "cpu-model/Penryn" or "cpu-feature/apic"
+func (n *NodeLabeller) missingRequiredCapabilities(labels map[string]string) []string {
+	minCPUModel := n.clusterConfig.GetMinCPUModel()
+	if minCPUModel == "" {
+		minCPUModel = util.DefaultMinCPUModel
+	}
+
+	missing := make([]string, 0)
+	if _, exists := labels[kubevirtv1.CPUModelLabel+minCPUModel]; !exists {
+		missing = append(missing, "cpu-model/"+minCPUModel)
+	}
+	for feature := range n.getMinCpuFeature() {
+		if _, exists := labels[kubevirtv1.CPUFeatureLabel+feature]; !exists {
+			missing = append(missing, "cpu-feature/"+feature)
+		}
+	}
+	sort.Strings(missing)
+	return missing
 }
 
 func (n *NodeLabeller) HostCapabilities() *api.Capabilities {
@@ -342,12 +798,12 @@ func (n *NodeLabeller) HostCapabilities() *api.Capabilities {
 
 // removeLabellerLabels removes labels from node
 func (n *NodeLabeller) removeLabellerLabels(node *v1.Node) {
-	for label := range node.Labels {
-		if isNodeLabellerLabel(label) {
-			delete(node.Labels, label)
-		}
-	}
+	node.Labels = mergeLabels(node.Labels, nil, n.isLabellerLabel)
+	n.removeDeprecatedAnnotations(node)
+}
 
+// removeDeprecatedAnnotations removes the annotations of the deprecated node-labeller
+func (n *NodeLabeller) removeDeprecatedAnnotations(node *v1.Node) {
 	for annotation := range node.Annotations {
 		if strings.HasPrefix(annotation, util.DeprecatedLabellerNamespaceAnnotation) {
 			delete(node.Annotations, annotation)
@@ -371,6 +827,19 @@ func isNodeRealtimeCapable() (bool, error) {
 	return fmt.Sprintf("%s = -1", kernelSchedRealtimeRuntimeInMicrosecods) == st, nil
 }
 
+// isPrefixedLabellerLabel checks the label against the labeller labels carrying the configured prefix
+func (n *NodeLabeller) isPrefixedLabellerLabel(label string) bool {
+	if n.labelPrefix == defaultLabelPrefix {
+		return false
+	}
+	for _, prefix := range prefixedLabels {
+		if strings.HasPrefix(label, withPrefix(prefix, n.labelPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
 func isNodeLabellerLabel(label string) bool {
 	for _, prefix := range nodeLabellerLabels {
 		if strings.HasPrefix(label, prefix) {
@@ -390,7 +859,7 @@ func (n *NodeLabeller) alertIfHostModelIsObsolete(originalNode *v1.Node, hostMod
 func (n *NodeLabeller) shouldAddCPUModelLabel(
 	cpuModelName string,
 	hostCpuModel *hostCPUModel,
-	featureLabels map[string]string,
+	supportedFeatures cpuFeatures,
 ) bool {
 	if cpuModelName == hostCpuModel.Name {
 		return true
@@ -412,9 +881,38 @@ func (n *NodeLabeller) shouldAddCPUModelLabel(
 	}
 	missingFeatures := make([]string, 0)
 	for f := range requiredFeatures {
-		if _, isFeatureSupported := featureLabels[kubevirtv1.CPUFeatureLabel+f]; !isFeatureSupported {
+		if !supportedFeatures[f] {
 			missingFeatures = append(missingFeatures, f)
 		}
 	}
 	return len(missingFeatures) == 0
 }
+
+// checkNewlyUnusableModels logs the cpu models which became unusable since the previous reconcile
+// and returns them, a regression usually means the host lost a cpu feature
+func (n *NodeLabeller) checkNewlyUnusableModels() []string {
+	var models []string
+	if n.cpuInfoSnapshot != nil {
+		models = n.cpuInfo.NewlyUnusable(*n.cpuInfoSnapshot)
+		if len(models) > 0 {
+			n.logger.Warningf("node-labeller found cpu models which are not usable anymore: %s", strings.Join(models, ", "))
+		}
+	}
+	snapshot := n.cpuInfo
+	n.cpuInfoSnapshot = &snapshot
+	return models
+}
+
+// setModelUsabilityAnnotation records how many of the cpu models known to libvirt are usable on the node,
+// a sudden drop hints at firmware or microcode issues disabling models
+func (n *NodeLabeller) setModelUsabilityAnnotation(node *v1.Node) {
+	usable, total := n.cpuInfo.ModelUsability()
+	if total == 0 {
+		delete(node.Annotations, kubevirtv1.CPUModelUsabilityAnnotation)
+		return
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.CPUModelUsabilityAnnotation] = fmt.Sprintf("%d/%d", usable, total)
+}
@@ -22,11 +22,20 @@
 package nodelabeller
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing/fstest"
 	"time"
 
+	kitlog "github.com/go-kit/kit/log"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	ioprometheusclient "github.com/prometheus/client_model/go"
 	k8sv1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,9 +47,11 @@ import (
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	"kubevirt.io/client-go/kubecli"
+	"kubevirt.io/client-go/log"
 
 	"kubevirt.io/kubevirt/pkg/testutils"
 	virtconfig "kubevirt.io/kubevirt/pkg/virt-config"
+	"kubevirt.io/kubevirt/pkg/virt-controller/watch/topology"
 	util "kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/util"
 )
 
@@ -62,13 +73,13 @@ var _ = Describe("Node-labeller ", func() {
 		mockQueue.Wait()
 	}
 
-	initNodeLabeller := func(kubevirt *kubevirtv1.KubeVirt, nodeLabels, nodeAnnotations map[string]string) {
+	initNodeLabeller := func(kubevirt *kubevirtv1.KubeVirt, nodeLabels, nodeAnnotations map[string]string, opts ...Option) {
 		var err error
 		config, _, _ = testutils.NewFakeClusterConfigUsingKV(kubevirt)
 		recorder = record.NewFakeRecorder(100)
 		recorder.IncludeObject = true
 
-		nlController, err = newNodeLabeller(config, virtClient, "testNode", k8sv1.NamespaceDefault, "testdata", recorder)
+		nlController, err = newNodeLabeller(config, virtClient, "testNode", k8sv1.NamespaceDefault, "testdata", recorder, opts...)
 		Expect(err).ToNot(HaveOccurred())
 
 		mockQueue = testutils.NewMockWorkQueue(nlController.queue)
@@ -124,11 +135,110 @@ var _ = Describe("Node-labeller ", func() {
 		}, 5*time.Second, time.Second).Should(Equal(1), "node should be re-queued if labeller process fails")
 	})
 
+	It("should reconcile the node when the trigger is signaled", func() {
+		trigger := make(chan struct{})
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+			Spec: kubevirtv1.KubeVirtSpec{
+				Configuration: kubevirtv1.KubeVirtConfiguration{
+					ObsoleteCPUModels: util.DefaultObsoleteCPUModels,
+					MinCPUModel:       "Penryn",
+				},
+			},
+		}
+		initNodeLabeller(kv, make(map[string]string), make(map[string]string), WithTrigger(trigger))
+		testutils.ExpectNodePatch(kubeClient)
+		Expect(nlController.execute()).To(BeTrue())
+		Expect(nlController.queue.Len()).To(BeZero())
+
+		go nlController.watchTrigger(stop)
+		mockQueue.ExpectAdds(1)
+		trigger <- struct{}{}
+		mockQueue.Wait()
+
+		reconciled := false
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			reconciled = true
+			return true, nil, nil
+		})
+		Expect(nlController.execute()).To(BeTrue())
+		Expect(reconciled).To(BeTrue())
+	})
+
 	It("should add host cpu model label", func() {
 		testutils.ExpectNodePatch(kubeClient, kubevirtv1.HostModelCPULabel)
 		res := nlController.execute()
 		Expect(res).To(BeTrue())
 	})
+	It("should not advertise denied cpu features supported by the host", func() {
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+		}
+		initNodeLabeller(kv, make(map[string]string), make(map[string]string), WithFeatureDenyList("vmx"))
+		Expect(nlController.supportedFeatures).To(ContainElement("vmx"))
+
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			return true, nil, nil
+		})
+		added, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ContainElement(kubevirtv1.CPUFeatureLabel + "apic"))
+		Expect(added).ToNot(ContainElement(kubevirtv1.CPUFeatureLabel + "vmx"))
+		Expect(added).ToNot(ContainElement(kubevirtv1.HostModelRequiredFeaturesLabel + "vmx"))
+	})
+
+	It("should not label the cpu models failing the model probe", func() {
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+		}
+		probe := func(model string) error {
+			if model == "Penryn" {
+				return fmt.Errorf("unsupported configuration: guest and host CPU are not compatible")
+			}
+			return nil
+		}
+		initNodeLabeller(kv, make(map[string]string), make(map[string]string), WithModelProbe(probe))
+
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			return true, nil, nil
+		})
+		added, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ContainElement(kubevirtv1.CPUModelLabel + "Skylake-Client-IBRS"))
+		Expect(added).ToNot(ContainElement(kubevirtv1.CPUModelLabel + "Penryn"))
+	})
+
+	It("should leave the labels not owned by the labeller untouched", func() {
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+		}
+		nodeLabels := map[string]string{
+			"node-role.kubernetes.io/worker":   "",
+			"scheduling.example.com/tier":      "gold",
+			kubevirtv1.CPUModelLabel + "Stale": "true",
+		}
+		initNodeLabeller(kv, nodeLabels, make(map[string]string))
+
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			return true, nil, nil
+		})
+		_, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(ConsistOf(kubevirtv1.CPUModelLabel + "Stale"))
+	})
+
 	It("should add host cpu required features", func() {
 		testutils.ExpectNodePatch(kubeClient, kubevirtv1.HostModelRequiredFeaturesLabel)
 		res := nlController.execute()
@@ -147,6 +257,160 @@ var _ = Describe("Node-labeller ", func() {
 		Expect(res).To(BeTrue())
 	})
 
+	It("should add disk aio labels", func() {
+		testutils.ExpectNodePatch(kubeClient,
+			kubevirtv1.DiskAIOLabel+"native",
+			kubevirtv1.DiskAIOLabel+"threads",
+			kubevirtv1.DiskAIOLabel+"io_uring",
+		)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add watchdog action labels", func() {
+		nlController.watchdogActions = []string{"reset", "poweroff", "pause"}
+		testutils.ExpectNodePatch(kubeClient,
+			kubevirtv1.WatchdogActionLabel+"reset",
+			kubevirtv1.WatchdogActionLabel+"poweroff",
+			kubevirtv1.WatchdogActionLabel+"pause",
+		)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add persistent memory labels", func() {
+		nlController.hostFS = fstest.MapFS{
+			"sys/bus/nd/devices/namespace0.0/size": {Data: []byte("17179869184\n")},
+		}
+		nlController.loadPersistentMemory()
+
+		testutils.ExpectNodePatch(kubeClient,
+			kubevirtv1.PMEMAvailableLabel,
+			`"`+kubevirtv1.PMEMCapacityLabel+`":"16Gi"`,
+		)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should not add persistent memory labels without persistent memory", func() {
+		nlController.hostFS = fstest.MapFS{}
+		nlController.loadPersistentMemory()
+
+		testutils.DoNotExpectNodePatch(kubeClient, kubevirtv1.PMEMAvailableLabel, kubevirtv1.PMEMCapacityLabel)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add NUMA tuning label", func() {
+		testutils.ExpectNodePatch(kubeClient, kubevirtv1.NUMATuningLabel)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add cpu topology labels", func() {
+		testutils.ExpectNodePatch(kubeClient,
+			kubevirtv1.CPUSocketsLabel,
+			kubevirtv1.CPUCoresPerSocketLabel,
+			kubevirtv1.CPUThreadsPerCoreLabel,
+		)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add cpu cache labels", func() {
+		testutils.ExpectNodePatch(kubeClient, `"`+kubevirtv1.CPUL3CacheLabel+`":"8192"`)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should not add cpu cache labels for caches missing from the host capabilities", func() {
+		testutils.DoNotExpectNodePatch(kubeClient, kubevirtv1.CPUL1CacheLabel, kubevirtv1.CPUL2CacheLabel)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	It("should add the cpu model count label", func() {
+		testutils.ExpectNodePatch(kubeClient,
+			`"`+kubevirtv1.CPUModelCountLabel+`":"`+strconv.Itoa(len(nlController.cpuInfo.usableModels))+`"`,
+		)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+	})
+
+	Context("schedulable rollup label", func() {
+		acceptPatches := func() {
+			kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+				return true, nil, nil
+			})
+		}
+
+		BeforeEach(func() {
+			nlController.kvmAvailable = true
+			addedNode.Labels[kubevirtv1.NodeLabellerSchedulableLabel] = "true"
+		})
+
+		It("should be kept when all preconditions are met", func() {
+			acceptPatches()
+			_, removed, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).ToNot(ContainElement(kubevirtv1.NodeLabellerSchedulableLabel))
+			Expect(nlController.LastAppliedLabels()).To(HaveKeyWithValue(kubevirtv1.NodeLabellerSchedulableLabel, "true"))
+		})
+
+		It("should be removed when kvm is not available", func() {
+			nlController.kvmAvailable = false
+			acceptPatches()
+			_, removed, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(ContainElement(kubevirtv1.NodeLabellerSchedulableLabel))
+		})
+
+		It("should be removed when no cpu model is usable", func() {
+			nlController.hostCapabilities = newSupportedFeatures(nil)
+			acceptPatches()
+			_, removed, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(ContainElement(kubevirtv1.NodeLabellerSchedulableLabel))
+		})
+
+		It("should be removed by a failed labelling pass and restored by the next successful one", func() {
+			acceptPatches()
+			domCapabilitiesFileName := nlController.domCapabilitiesFileName
+			nlController.domCapabilitiesFileName = "missing.xml"
+			_, removed, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(ContainElement(kubevirtv1.NodeLabellerSchedulableLabel))
+
+			delete(addedNode.Labels, kubevirtv1.NodeLabellerSchedulableLabel)
+			nlController.domCapabilitiesFileName = domCapabilitiesFileName
+			added, _, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(added).To(ContainElement(kubevirtv1.NodeLabellerSchedulableLabel))
+		})
+	})
+
+	It("should mark the node while no cpu model is usable", func() {
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			return true, nil, nil
+		})
+		usableModels := nlController.hostCapabilities.Items()
+
+		By("losing all usable cpu models")
+		nlController.hostCapabilities = newSupportedFeatures(nil)
+		added, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ContainElement(kubevirtv1.NoUsableCPUModelLabel))
+		Expect(nlController.LastAppliedLabels()).To(HaveKeyWithValue(kubevirtv1.NoUsableCPUModelLabel, "true"))
+
+		By("getting a usable cpu model back")
+		addedNode.Labels = nlController.LastAppliedLabels()
+		nlController.hostCapabilities = newSupportedFeatures(usableModels)
+		_, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(ContainElement(kubevirtv1.NoUsableCPUModelLabel))
+		Expect(nlController.LastAppliedLabels()).ToNot(HaveKey(kubevirtv1.NoUsableCPUModelLabel))
+	})
+
 	It("should add usable cpu model labels for the host cpu model", func() {
 		testutils.ExpectNodePatch(kubeClient,
 			kubevirtv1.HostModelCPULabel+"Skylake-Client-IBRS",
@@ -175,6 +439,394 @@ var _ = Describe("Node-labeller ", func() {
 		Expect(res).To(BeTrue())
 	})
 
+	It("should not add cpu model labels older than the minimal cpu model", func() {
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+			Spec: kubevirtv1.KubeVirtSpec{
+				Configuration: kubevirtv1.KubeVirtConfiguration{
+					ObsoleteCPUModels: util.DefaultObsoleteCPUModels,
+					MinCPUModel:       "IvyBridge",
+				},
+			},
+		}
+		initNodeLabeller(kv, make(map[string]string), make(map[string]string))
+
+		testutils.DoNotExpectNodePatch(kubeClient,
+			kubevirtv1.CPUModelLabel+"Penryn",
+			kubevirtv1.SupportedHostModelMigrationCPU+"Penryn",
+		)
+		added, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ContainElement(kubevirtv1.CPUModelLabel + "Skylake-Client-IBRS"))
+	})
+
+	It("should report the capabilities of the minimal cpu model the node is missing", func() {
+		kv := &kubevirtv1.KubeVirt{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubevirt",
+				Namespace: "kubevirt",
+			},
+			Spec: kubevirtv1.KubeVirtSpec{
+				Configuration: kubevirtv1.KubeVirtConfiguration{
+					ObsoleteCPUModels: util.DefaultObsoleteCPUModels,
+					MinCPUModel:       "Opteron_G2",
+				},
+			},
+		}
+		initNodeLabeller(kv, make(map[string]string), make(map[string]string))
+
+		testutils.ExpectNodePatch(kubeClient)
+		res := nlController.execute()
+		Expect(res).To(BeTrue())
+
+		for _, capability := range []string{"cpu-model/Opteron_G2", "cpu-feature/svm"} {
+			metric := &ioprometheusclient.Metric{}
+			Expect(missingRequiredCapability.WithLabelValues(capability).Write(metric)).To(Succeed())
+			Expect(metric.GetGauge().GetValue()).To(BeEquivalentTo(1), "capability %s should be reported as missing", capability)
+		}
+	})
+
+	Context("reconcile metrics", func() {
+		reconcileCount := func(result string) float64 {
+			metric := &ioprometheusclient.Metric{}
+			Expect(reconcileTotal.WithLabelValues(result).Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue()
+		}
+
+		It("should count the successful, unchanged and failed reconciles", func() {
+			success := reconcileCount(reconcileResultSuccess)
+			noop := reconcileCount(reconcileResultNoop)
+			applyError := reconcileCount(reconcileResultApplyError)
+
+			By("failing to patch the node")
+			_, _, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).To(HaveOccurred())
+			Expect(reconcileCount(reconcileResultApplyError)).To(Equal(applyError + 1))
+
+			By("patching the node")
+			kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+				return true, nil, nil
+			})
+			_, _, err = nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reconcileCount(reconcileResultSuccess)).To(Equal(success + 1))
+
+			By("reconciling the unchanged node")
+			addedNode.Labels, addedNode.Annotations, err = nlController.Reconcile(context.Background(), addedNode)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, err = nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reconcileCount(reconcileResultNoop)).To(Equal(noop + 1))
+			Expect(reconcileCount(reconcileResultSuccess)).To(Equal(success + 1))
+		})
+
+		It("should count the host capabilities which can not be parsed", func() {
+			parseError := reconcileCount(reconcileResultParseError)
+			_, err := newNodeLabeller(config, virtClient, "testNode", k8sv1.NamespaceDefault, "testdata/missing", recorder)
+			Expect(err).To(HaveOccurred())
+			Expect(reconcileCount(reconcileResultParseError)).To(Equal(parseError + 1))
+		})
+
+		It("should report the labelled cpu features and clear the disappeared ones", func() {
+			reportedFeatures := func() []string {
+				metrics := make(chan prometheus.Metric, 1000)
+				nodeCPUFeature.Collect(metrics)
+				close(metrics)
+				features := []string{}
+				for metric := range metrics {
+					m := &ioprometheusclient.Metric{}
+					Expect(metric.Write(m)).To(Succeed())
+					Expect(m.GetGauge().GetValue()).To(BeEquivalentTo(1))
+					features = append(features, m.GetLabel()[0].GetValue())
+				}
+				return features
+			}
+			kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+				return true, nil, nil
+			})
+
+			_, _, err := nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reportedFeatures()).To(ContainElements("apic", "vmx"))
+
+			By("reconciling the node after a feature disappeared")
+			var supportedFeatures []string
+			for _, feature := range nlController.supportedFeatures {
+				if feature != "apic" {
+					supportedFeatures = append(supportedFeatures, feature)
+				}
+			}
+			nlController.supportedFeatures = supportedFeatures
+			_, _, err = nlController.ReconcileAndReport("testNode")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reportedFeatures()).To(ContainElement("vmx"))
+			Expect(reportedFeatures()).ToNot(ContainElement("apic"))
+		})
+	})
+
+	It("should not patch the node if the labels and annotations did not change since the last reconcile", func() {
+		patches := 0
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			patchAction, ok := action.(testing.PatchAction)
+			Expect(ok).To(BeTrue())
+			var ops []struct {
+				Op    string            `json:"op"`
+				Path  string            `json:"path"`
+				Value map[string]string `json:"value"`
+			}
+			Expect(json.Unmarshal(patchAction.GetPatch(), &ops)).To(Succeed())
+			for _, op := range ops {
+				switch {
+				case op.Op == "replace" && op.Path == "/metadata/labels":
+					addedNode.Labels = op.Value
+				case op.Op == "replace" && op.Path == "/metadata/annotations":
+					addedNode.Annotations = op.Value
+				}
+			}
+			patches++
+			return true, nil, nil
+		})
+
+		_, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patches).To(Equal(1))
+		Expect(nlController.LastAppliedLabels()).To(HaveKey(kubevirtv1.CPUModelLabel + "Penryn"))
+
+		added, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(BeEmpty())
+		Expect(removed).To(BeEmpty())
+		Expect(patches).To(Equal(1))
+
+		By("removing a label from the node")
+		delete(addedNode.Labels, kubevirtv1.CPUModelLabel+"Penryn")
+		added, _, err = nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ConsistOf(kubevirtv1.CPUModelLabel + "Penryn"))
+		Expect(patches).To(Equal(2))
+
+		By("removing an annotation from the node")
+		usability := addedNode.Annotations[kubevirtv1.CPUModelUsabilityAnnotation]
+		Expect(usability).ToNot(BeEmpty())
+		delete(addedNode.Annotations, kubevirtv1.CPUModelUsabilityAnnotation)
+		added, removed, err = nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(BeEmpty())
+		Expect(removed).To(BeEmpty())
+		Expect(patches).To(Equal(3))
+		Expect(addedNode.Annotations).To(HaveKeyWithValue(kubevirtv1.CPUModelUsabilityAnnotation, usability))
+
+		By("reconciling the repaired node again")
+		_, _, err = nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patches).To(Equal(3))
+	})
+
+	It("should remove the labels as stale while the host capabilities are unavailable", func() {
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			patchAction, ok := action.(testing.PatchAction)
+			Expect(ok).To(BeTrue())
+			var ops []struct {
+				Op    string            `json:"op"`
+				Path  string            `json:"path"`
+				Value map[string]string `json:"value"`
+			}
+			Expect(json.Unmarshal(patchAction.GetPatch(), &ops)).To(Succeed())
+			for _, op := range ops {
+				switch {
+				case op.Op == "replace" && op.Path == "/metadata/labels":
+					addedNode.Labels = op.Value
+				case op.Op == "replace" && op.Path == "/metadata/annotations":
+					addedNode.Annotations = op.Value
+				}
+			}
+			return true, nil, nil
+		})
+		addedNode.Labels["unrelated"] = "true"
+		modelLabel := kubevirtv1.CPUModelLabel + "Penryn"
+
+		_, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addedNode.Labels).To(HaveKey(modelLabel))
+
+		By("losing the host capabilities")
+		nlController.capabilitiesProbe = func() error {
+			return fmt.Errorf("libvirt is not reachable")
+		}
+		added, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(BeEmpty())
+		Expect(removed).To(ContainElement(modelLabel))
+		Expect(addedNode.Labels).To(Equal(map[string]string{"unrelated": "true"}))
+		Expect(addedNode.Annotations).To(HaveKeyWithValue(kubevirtv1.CPULabelsStaleAnnotation, "true"))
+
+		By("regaining the host capabilities")
+		nlController.capabilitiesProbe = func() error {
+			return nil
+		}
+		added, _, err = nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(ContainElement(modelLabel))
+		Expect(addedNode.Labels).To(HaveKeyWithValue("unrelated", "true"))
+		Expect(addedNode.Annotations).ToNot(HaveKey(kubevirtv1.CPULabelsStaleAnnotation))
+	})
+
+	It("should migrate labels in a former format and drop unknown labels", func() {
+		legacyLabel := util.DeprecatedLabelNamespace + util.DeprecatedcpuModelPrefix + "Penryn"
+		unknownLabel := "memory-hotplug.node.kubevirt.io/plug"
+		addedNode.Labels = map[string]string{
+			legacyLabel:  "true",
+			unknownLabel: "true",
+			"unrelated":  "true",
+		}
+		testutils.ExpectNodePatch(kubeClient)
+
+		added, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(ConsistOf(legacyLabel, unknownLabel))
+		Expect(added).To(ContainElement(kubevirtv1.CPUModelLabel + "Penryn"))
+	})
+
+	It("should keep the tsc frequency label of virt-controller", func() {
+		tscLabel := topology.ToTSCSchedulableLabel(2800000000)
+		addedNode.Labels = map[string]string{tscLabel: "true"}
+		testutils.ExpectNodePatch(kubeClient)
+
+		_, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).ToNot(ContainElement(tscLabel))
+		Expect(addedNode.Labels).To(HaveKeyWithValue(tscLabel, "true"))
+	})
+
+	It("should report the cpu models which became unusable between two reconciles", func() {
+		buffer := bytes.NewBuffer(nil)
+		nlController.logger = log.MakeLogger(kitlog.NewJSONLogger(buffer))
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			return true, nil, nil
+		})
+
+		nlController.domCapabilitiesFileName = "domcapabilities_cpu_hotplug.xml"
+		_, _, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buffer.String()).ToNot(ContainSubstring("not usable anymore"))
+
+		By("losing the Skylake-Client-IBRS model")
+		nlController.domCapabilitiesFileName = "domcapabilities_nested.xml"
+		_, _, err = nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buffer.String()).To(ContainSubstring("cpu models which are not usable anymore: Skylake-Client-IBRS"))
+	})
+
+	It("should report the labels added and removed by a reconcile", func() {
+		staleLabel := kubevirtv1.CPUModelLabel + "Conroe"
+		originalLabels := map[string]string{
+			staleLabel:  "true",
+			"unrelated": "true",
+		}
+		addedNode.Labels = originalLabels
+
+		var patchedLabels map[string]string
+		kubeClient.Fake.PrependReactor("patch", "nodes", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			patchAction, ok := action.(testing.PatchAction)
+			Expect(ok).To(BeTrue())
+			var ops []struct {
+				Op    string            `json:"op"`
+				Path  string            `json:"path"`
+				Value map[string]string `json:"value"`
+			}
+			Expect(json.Unmarshal(patchAction.GetPatch(), &ops)).To(Succeed())
+			for _, op := range ops {
+				if op.Op == "replace" && op.Path == "/metadata/labels" {
+					patchedLabels = op.Value
+				}
+			}
+			return true, nil, nil
+		})
+
+		added, removed, err := nlController.ReconcileAndReport("testNode")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patchedLabels).ToNot(BeEmpty())
+
+		expectedAdded := []string{}
+		for key, value := range patchedLabels {
+			if originalValue, exists := originalLabels[key]; !exists || originalValue != value {
+				expectedAdded = append(expectedAdded, key)
+			}
+		}
+		Expect(added).To(ConsistOf(expectedAdded))
+		Expect(added).To(ContainElement(kubevirtv1.CPUModelLabel + "Penryn"))
+		Expect(removed).To(ConsistOf(staleLabel))
+		Expect(patchedLabels).ToNot(HaveKey(staleLabel))
+		Expect(patchedLabels).To(HaveKey("unrelated"))
+	})
+
+	Context("reconcile without a cluster", func() {
+		DescribeTable("should compute the labels of the node from the capabilities", func(domCapabilitiesFileName string, expectedLabels map[string]string, unexpectedLabels []string) {
+			nlController.domCapabilitiesFileName = domCapabilitiesFileName
+			Expect(nlController.loadAll()).To(Succeed())
+			node := newNode("testNode", map[string]string{"unrelated": "true"}, nil)
+
+			labels, annotations, err := nlController.Reconcile(context.Background(), node)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(HaveKeyWithValue("unrelated", "true"))
+			for key, value := range expectedLabels {
+				Expect(labels).To(HaveKeyWithValue(key, value))
+			}
+			for _, key := range unexpectedLabels {
+				Expect(labels).ToNot(HaveKey(key))
+			}
+			Expect(annotations).To(HaveKey(kubevirtv1.CPUFingerprintAnnotation))
+
+			Expect(node.Labels).To(Equal(map[string]string{"unrelated": "true"}), "the given node must not be modified")
+			Expect(kubeClient.Actions()).To(BeEmpty(), "the cluster must not be reached")
+		},
+			Entry("on an intel host", "virsh_domcapabilities.xml",
+				map[string]string{
+					kubevirtv1.CPUModelLabel + "Penryn":                  "true",
+					kubevirtv1.HostModelCPULabel + "Skylake-Client-IBRS": "true",
+					kubevirtv1.CPUVendorIDSpoofLabel:                     "false",
+				},
+				[]string{kubevirtv1.CPUModelLabel + "EPYC-IBPB"},
+			),
+			Entry("on an amd host with sev", "domcapabilities_sev.xml",
+				map[string]string{
+					kubevirtv1.CPUModelLabel + "EPYC-IBPB":     "true",
+					kubevirtv1.HostModelCPULabel + "EPYC-IBPB": "true",
+					kubevirtv1.SEVLabel:                        "",
+				},
+				[]string{kubevirtv1.HostModelCPULabel + "Skylake-Client-IBRS"},
+			),
+			Entry("on a host with the hyperv vendor_id enlightenment", "domcapabilities_hyperv.xml",
+				map[string]string{kubevirtv1.CPUVendorIDSpoofLabel: "true"},
+				nil,
+			),
+			Entry("on a host without usable cpu models", "virsh_domcapabilities_nothing_usable.xml",
+				nil,
+				[]string{kubevirtv1.CPUModelLabel + "Penryn", kubevirtv1.CPUModelLabel + "Haswell"},
+			),
+		)
+
+		It("should leave the labels of a node which skips the labelling untouched", func() {
+			node := newNode("testNode", map[string]string{"unrelated": "true"}, map[string]string{kubevirtv1.LabellerSkipNodeAnnotation: "true"})
+
+			labels, annotations, err := nlController.Reconcile(context.Background(), node)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(Equal(node.Labels))
+			Expect(annotations).To(Equal(node.Annotations))
+		})
+
+		It("should fail when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, _, err := nlController.Reconcile(ctx, newNode("testNode", nil, nil))
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
 	AfterEach(func() {
 		close(stop)
 	})
@@ -0,0 +1,203 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// NodeLabels is the typed representation of the labels emitted by the node-labeller,
+// ToMap converts it to the actual node labels
+type NodeLabels struct {
+	// Models are the usable cpu models, which are host-model migration targets as well
+	Models []string
+	// MigrationModels are additional host-model migration targets, i.e. the non-obsolete host cpu model
+	MigrationModels []string
+	ModelCount      int
+	NoUsableModel   bool
+	Features        []string
+	FeatureFamilies []string
+	// CPUInfoFlags are the /proc/cpuinfo flags which libvirt does not report as cpu features
+	CPUInfoFlags []string
+	Vendor       string
+	HostModel    string
+	// HostModelRequiredFeatures are the features the host-model cpu requires on top of its model
+	HostModelRequiredFeatures []string
+	HostModelObsolete         bool
+	HypervFeatures            []string
+	VendorIDSpoof             bool
+	KVMHintDedicated          bool
+	CPUVulnMitigated          *bool
+	TSC                       *TSCLabels
+	Realtime                  bool
+	RealtimeCapable           bool
+	CPUGovernor               string
+	RDTCAT                    bool
+	RDTMBA                    bool
+	IOMMU                     bool
+	TPM                       bool
+	UEFI                      bool
+	SecureBoot                bool
+	Virtiofs                  bool
+	SEV                       bool
+	SEVES                     bool
+	GICVersions               []string
+	DiskAIOModes              []string
+	MaxVCPUs                  int
+	CPUHotplug                bool
+	WatchdogActions           []string
+	// PMEMCapacity is the persistent memory capacity in bytes, 0 without persistent memory
+	PMEMCapacity    int64
+	NUMATuning      bool
+	NUMANodes       int
+	VirtIOIOMMU     bool
+	Virgl           bool
+	MemoryHotUnplug bool
+	// HugepageSizes are the hugepage sizes with a nonzero pool, e.g. 2Mi
+	HugepageSizes []string
+	// DeviceCounts maps the resource names of the permitted host devices to the number of assignable devices
+	DeviceCounts map[string]int64
+	Microcode    string
+	Topology     *TopologyLabels
+	// CacheSizesKiB maps the cache levels to the size of a single data or unified cache
+	CacheSizesKiB map[int]uint64
+	Schedulable   bool
+}
+
+// TSCLabels describes the tsc counter of the host
+type TSCLabels struct {
+	Frequency int64
+	Scalable  bool
+}
+
+// TopologyLabels describes the cpu topology of the host
+type TopologyLabels struct {
+	Sockets        int
+	CoresPerSocket int
+	ThreadsPerCore int
+}
+
+// ToMap returns the node labels, e.g. "cpu-feature.node.kubevirt.io/vmx": "true"
+func (l NodeLabels) ToMap() map[string]string {
+	labels := make(map[string]string)
+	setAll := func(prefix string, names []string, value string) {
+		for _, name := range names {
+			labels[prefix+name] = value
+		}
+	}
+	setIf := func(condition bool, key, value string) {
+		if condition {
+			labels[key] = value
+		}
+	}
+
+	setAll(kubevirtv1.CPUFeatureLabel, l.Features, "true")
+	setAll(kubevirtv1.CPUFeatureFamilyLabel, l.FeatureFamilies, "true")
+	setAll(kubevirtv1.CPUInfoFlagLabel, l.CPUInfoFlags, "true")
+	setAll(kubevirtv1.CPUModelLabel, l.Models, "true")
+	setAll(kubevirtv1.SupportedHostModelMigrationCPU, l.Models, "true")
+	setAll(kubevirtv1.SupportedHostModelMigrationCPU, l.MigrationModels, "true")
+	labels[kubevirtv1.CPUModelCountLabel] = strconv.Itoa(l.ModelCount)
+	setIf(l.NoUsableModel, kubevirtv1.NoUsableCPUModelLabel, "true")
+
+	labels[kubevirtv1.CPUModelVendorLabel+l.Vendor] = "true"
+	setIf(l.HostModel != "", kubevirtv1.HostModelCPULabel+l.HostModel, "true")
+	setAll(kubevirtv1.HostModelRequiredFeaturesLabel, l.HostModelRequiredFeatures, "true")
+	setIf(l.HostModelObsolete, kubevirtv1.NodeHostModelIsObsoleteLabel, "true")
+	setAll(kubevirtv1.HypervLabel, l.HypervFeatures, "true")
+	labels[kubevirtv1.CPUVendorIDSpoofLabel] = strconv.FormatBool(l.VendorIDSpoof)
+	labels[kubevirtv1.KVMHintDedicatedLabel] = strconv.FormatBool(l.KVMHintDedicated)
+	if l.CPUVulnMitigated != nil {
+		labels[kubevirtv1.CPUVulnMitigatedLabel] = strconv.FormatBool(*l.CPUVulnMitigated)
+	}
+
+	if l.TSC != nil {
+		labels[kubevirtv1.CPUTimerLabel+"tsc-frequency"] = strconv.FormatInt(l.TSC.Frequency, 10)
+		labels[kubevirtv1.CPUTimerLabel+"tsc-scalable"] = strconv.FormatBool(l.TSC.Scalable)
+	}
+
+	setIf(l.Realtime, kubevirtv1.RealtimeLabel, "")
+	labels[kubevirtv1.RealtimeCapableLabel] = strconv.FormatBool(l.RealtimeCapable)
+	setIf(l.CPUGovernor != "", kubevirtv1.CPUGovernorLabel+l.CPUGovernor, "true")
+	labels[kubevirtv1.RDTCATLabel] = strconv.FormatBool(l.RDTCAT)
+	labels[kubevirtv1.RDTMBALabel] = strconv.FormatBool(l.RDTMBA)
+	labels[kubevirtv1.IOMMULabel] = strconv.FormatBool(l.IOMMU)
+	labels[kubevirtv1.TPMLabel] = strconv.FormatBool(l.TPM)
+	labels[kubevirtv1.UEFILabel] = strconv.FormatBool(l.UEFI)
+	labels[kubevirtv1.SecureBootLabel] = strconv.FormatBool(l.SecureBoot)
+	labels[kubevirtv1.VirtiofsLabel] = strconv.FormatBool(l.Virtiofs)
+	setIf(l.SEV, kubevirtv1.SEVLabel, "")
+	setIf(l.SEVES, kubevirtv1.SEVESLabel, "")
+
+	for _, version := range l.GICVersions {
+		labels[kubevirtv1.GICVersionLabel+"v"+version] = "true"
+	}
+	setAll(kubevirtv1.DiskAIOLabel, l.DiskAIOModes, "supported")
+	setAll(kubevirtv1.WatchdogActionLabel, l.WatchdogActions, "supported")
+	setIf(l.MaxVCPUs > 0, kubevirtv1.MaxVCPULabel, strconv.Itoa(l.MaxVCPUs))
+	labels[kubevirtv1.CPUHotplugLabel] = strconv.FormatBool(l.CPUHotplug)
+	if l.PMEMCapacity > 0 {
+		labels[kubevirtv1.PMEMAvailableLabel] = "true"
+		labels[kubevirtv1.PMEMCapacityLabel] = resource.NewQuantity(l.PMEMCapacity, resource.BinarySI).String()
+	}
+	setIf(l.NUMATuning, kubevirtv1.NUMATuningLabel, "true")
+	labels[kubevirtv1.NUMALabel] = strconv.FormatBool(l.NUMANodes > 1)
+	setIf(l.NUMANodes > 0, kubevirtv1.NUMANodesLabel, strconv.Itoa(l.NUMANodes))
+	setIf(l.VirtIOIOMMU, kubevirtv1.VirtIOIOMMULabel, "true")
+	setIf(l.Virgl, kubevirtv1.VirglLabel, "supported")
+	setIf(l.MemoryHotUnplug, kubevirtv1.MemoryHotUnplugLabel, "true")
+	for _, size := range l.HugepageSizes {
+		for _, hugepages := range hugepageSizes {
+			setIf(hugepages.size == size, hugepages.label, "true")
+		}
+	}
+	for resourceName, count := range l.DeviceCounts {
+		labels[kubevirtv1.DeviceCountLabel+deviceCountLabelName(resourceName)] = strconv.FormatInt(count, 10)
+	}
+	setIf(l.Microcode != "", kubevirtv1.CPUMicrocodeLabel, l.Microcode)
+
+	if l.Topology != nil {
+		labels[kubevirtv1.CPUSocketsLabel] = strconv.Itoa(l.Topology.Sockets)
+		labels[kubevirtv1.CPUCoresPerSocketLabel] = strconv.Itoa(l.Topology.CoresPerSocket)
+		labels[kubevirtv1.CPUThreadsPerCoreLabel] = strconv.Itoa(l.Topology.ThreadsPerCore)
+	}
+	for level, sizeKiB := range l.CacheSizesKiB {
+		if label, exists := cacheLevelLabels[level]; exists {
+			labels[label] = strconv.FormatUint(sizeKiB, 10)
+		}
+	}
+
+	setIf(l.Schedulable, kubevirtv1.NodeLabellerSchedulableLabel, "true")
+	return labels
+}
+
+// sortedKeys returns the sorted names of the features
+func sortedKeys(features cpuFeatures) []string {
+	keys := make([]string, 0, len(features))
+	for key := range features {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Node labels", func() {
+
+	It("should convert the typed labels to node labels", func() {
+		labels := NodeLabels{
+			Models:          []string{"Penryn"},
+			MigrationModels: []string{"Skylake-Client-IBRS"},
+			ModelCount:      2,
+			Features:        []string{"vmx"},
+			Vendor:          "Intel",
+			HostModel:       "Skylake-Client-IBRS",
+			TSC:             &TSCLabels{Frequency: 2400000000, Scalable: true},
+			SEV:             true,
+			DiskAIOModes:    []string{"native"},
+			PMEMCapacity:    1 << 30,
+			Topology:        &TopologyLabels{Sockets: 1, CoresPerSocket: 4, ThreadsPerCore: 2},
+			CacheSizesKiB:   map[int]uint64{3: 8192},
+			Schedulable:     true,
+		}
+		Expect(labels.ToMap()).To(Equal(map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn":                               "true",
+			kubevirtv1.SupportedHostModelMigrationCPU + "Penryn":              "true",
+			kubevirtv1.SupportedHostModelMigrationCPU + "Skylake-Client-IBRS": "true",
+			kubevirtv1.CPUModelCountLabel:                                     "2",
+			kubevirtv1.CPUFeatureLabel + "vmx":                                "true",
+			kubevirtv1.CPUModelVendorLabel + "Intel":                          "true",
+			kubevirtv1.HostModelCPULabel + "Skylake-Client-IBRS":              "true",
+			kubevirtv1.CPUTimerLabel + "tsc-frequency":                        "2400000000",
+			kubevirtv1.CPUTimerLabel + "tsc-scalable":                         "true",
+			kubevirtv1.RealtimeCapableLabel:                                   "false",
+			kubevirtv1.IOMMULabel:                                             "false",
+			kubevirtv1.KVMHintDedicatedLabel:                                  "false",
+			kubevirtv1.CPUVendorIDSpoofLabel:                                  "false",
+			kubevirtv1.TPMLabel:                                               "false",
+			kubevirtv1.UEFILabel:                                              "false",
+			kubevirtv1.SecureBootLabel:                                        "false",
+			kubevirtv1.VirtiofsLabel:                                          "false",
+			kubevirtv1.NUMALabel:                                              "false",
+			kubevirtv1.CPUHotplugLabel:                                        "false",
+			kubevirtv1.RDTCATLabel:                                            "false",
+			kubevirtv1.RDTMBALabel:                                            "false",
+			kubevirtv1.SEVLabel:                                               "",
+			kubevirtv1.DiskAIOLabel + "native":                                "supported",
+			kubevirtv1.PMEMAvailableLabel:                                     "true",
+			kubevirtv1.PMEMCapacityLabel:                                      "1Gi",
+			kubevirtv1.CPUSocketsLabel:                                        "1",
+			kubevirtv1.CPUCoresPerSocketLabel:                                 "4",
+			kubevirtv1.CPUThreadsPerCoreLabel:                                 "2",
+			kubevirtv1.CPUL3CacheLabel:                                        "8192",
+			kubevirtv1.NodeLabellerSchedulableLabel:                           "true",
+		}))
+	})
+
+	It("should only label the no usable cpu model condition when it applies", func() {
+		Expect(NodeLabels{}.ToMap()).ToNot(HaveKey(kubevirtv1.NoUsableCPUModelLabel))
+		Expect(NodeLabels{NoUsableModel: true}.ToMap()).To(HaveKeyWithValue(kubevirtv1.NoUsableCPUModelLabel, "true"))
+	})
+})
@@ -0,0 +1,39 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+// numaSource returns the number of NUMA nodes of the host, 0 if it is unknown
+type numaSource func() int
+
+// hostNUMANodes counts the NUMA cells of the host topology reported by libvirt
+func (n *NodeLabeller) hostNUMANodes() int {
+	if n.capabilities == nil {
+		return 0
+	}
+	return n.capabilities.GetNUMANodes()
+}
+
+// addNUMALabels labels the number of NUMA nodes of the host. A guest NUMA topology can only be
+// passed through to distinct host NUMA nodes on a host with more than one of them.
+func (n *NodeLabeller) addNUMALabels(labels *NodeLabels) {
+	if n.numaSource == nil {
+		return
+	}
+	labels.NUMANodes = n.numaSource()
+}
@@ -0,0 +1,60 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/api"
+)
+
+var _ = Describe("NUMA", func() {
+
+	DescribeTable("should label the NUMA nodes of the NUMA source", func(nodes int, expectedNUMA string, expectedNodes string) {
+		n := &NodeLabeller{numaSource: func() int {
+			return nodes
+		}}
+		labels := &NodeLabels{}
+		n.addNUMALabels(labels)
+
+		labelMap := labels.ToMap()
+		Expect(labelMap).To(HaveKeyWithValue(kubevirtv1.NUMALabel, expectedNUMA))
+		if expectedNodes == "" {
+			Expect(labelMap).ToNot(HaveKey(kubevirtv1.NUMANodesLabel))
+		} else {
+			Expect(labelMap).To(HaveKeyWithValue(kubevirtv1.NUMANodesLabel, expectedNodes))
+		}
+	},
+		Entry("on a multi NUMA node host", 4, "true", "4"),
+		Entry("on a single NUMA node host", 1, "false", "1"),
+		Entry("when the NUMA nodes are unknown", 0, "false", ""),
+	)
+
+	It("should count the NUMA nodes of the host capabilities", func() {
+		n := &NodeLabeller{}
+		Expect(n.hostNUMANodes()).To(BeZero())
+
+		n.capabilities = &api.Capabilities{}
+		n.capabilities.Host.Topology.Cells.Cell = make([]api.Cell, 2)
+		Expect(n.hostNUMANodes()).To(Equal(2))
+	})
+})
@@ -0,0 +1,363 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// LabelCategory groups the labels emitted by the node-labeller
+type LabelCategory string
+
+const (
+	CPUModelCategory   LabelCategory = "cpu-model"
+	CPUFeatureCategory LabelCategory = "cpu-feature"
+	CPUTimerCategory   LabelCategory = "cpu-timer"
+	HypervCategory     LabelCategory = "hyperv"
+	RealtimeCategory   LabelCategory = "realtime"
+	SEVCategory        LabelCategory = "sev"
+	DeviceCategory     LabelCategory = "device"
+	TopologyCategory   LabelCategory = "topology"
+
+	defaultLabelPrefix = "node.kubevirt.io"
+)
+
+// labelCategories maps the label prefixes to their category
+var labelCategories = map[string]LabelCategory{
+	kubevirtv1.CPUModelLabel:                  CPUModelCategory,
+	kubevirtv1.SupportedHostModelMigrationCPU: CPUModelCategory,
+	kubevirtv1.CPUModelVendorLabel:            CPUModelCategory,
+	kubevirtv1.HostModelCPULabel:              CPUModelCategory,
+	kubevirtv1.HostModelRequiredFeaturesLabel: CPUModelCategory,
+	kubevirtv1.NodeHostModelIsObsoleteLabel:   CPUModelCategory,
+	kubevirtv1.CPUMicrocodeLabel:              CPUModelCategory,
+	kubevirtv1.CPUModelCountLabel:             CPUModelCategory,
+	kubevirtv1.NoUsableCPUModelLabel:          CPUModelCategory,
+	kubevirtv1.CPUFeatureLabel:                CPUFeatureCategory,
+	kubevirtv1.CPUFeatureFamilyLabel:          CPUFeatureCategory,
+	kubevirtv1.CPUInfoFlagLabel:               CPUFeatureCategory,
+	kubevirtv1.KVMHintDedicatedLabel:          CPUFeatureCategory,
+	kubevirtv1.CPUVulnMitigatedLabel:          CPUFeatureCategory,
+	kubevirtv1.CPUTimerLabel:                  CPUTimerCategory,
+	kubevirtv1.HypervLabel:                    HypervCategory,
+	kubevirtv1.CPUVendorIDSpoofLabel:          HypervCategory,
+	kubevirtv1.RealtimeLabel:                  RealtimeCategory,
+	kubevirtv1.RealtimeCapableLabel:           RealtimeCategory,
+	kubevirtv1.CPUGovernorLabel:               RealtimeCategory,
+	kubevirtv1.RDTCATLabel:                    RealtimeCategory,
+	kubevirtv1.RDTMBALabel:                    RealtimeCategory,
+	kubevirtv1.SEVLabel:                       SEVCategory,
+	kubevirtv1.SEVESLabel:                     SEVCategory,
+	kubevirtv1.DiskAIOLabel:                   DeviceCategory,
+	kubevirtv1.VirtIOIOMMULabel:               DeviceCategory,
+	kubevirtv1.MemoryHotUnplugLabel:           DeviceCategory,
+	kubevirtv1.GICVersionLabel:                DeviceCategory,
+	kubevirtv1.PMEMAvailableLabel:             DeviceCategory,
+	kubevirtv1.PMEMCapacityLabel:              DeviceCategory,
+	kubevirtv1.VirglLabel:                     DeviceCategory,
+	kubevirtv1.WatchdogActionLabel:            DeviceCategory,
+	kubevirtv1.IOMMULabel:                     DeviceCategory,
+	kubevirtv1.Hugepages2MiLabel:              DeviceCategory,
+	kubevirtv1.Hugepages1GiLabel:              DeviceCategory,
+	kubevirtv1.TPMLabel:                       DeviceCategory,
+	kubevirtv1.UEFILabel:                      DeviceCategory,
+	kubevirtv1.SecureBootLabel:                DeviceCategory,
+	kubevirtv1.VirtiofsLabel:                  DeviceCategory,
+	kubevirtv1.DeviceCountLabel:               DeviceCategory,
+	kubevirtv1.NUMATuningLabel:                TopologyCategory,
+	kubevirtv1.MaxVCPULabel:                   TopologyCategory,
+	kubevirtv1.CPUHotplugLabel:                TopologyCategory,
+	kubevirtv1.NUMALabel:                      TopologyCategory,
+	kubevirtv1.NUMANodesLabel:                 TopologyCategory,
+	kubevirtv1.CPUSocketsLabel:                TopologyCategory,
+	kubevirtv1.CPUCoresPerSocketLabel:         TopologyCategory,
+	kubevirtv1.CPUThreadsPerCoreLabel:         TopologyCategory,
+	kubevirtv1.CPUL1CacheLabel:                TopologyCategory,
+	kubevirtv1.CPUL2CacheLabel:                TopologyCategory,
+	kubevirtv1.CPUL3CacheLabel:                TopologyCategory,
+}
+
+// labelCategoryPriority orders the label categories by their value for scheduling, the labels of the last
+// categories are dropped first when the label budget is exceeded. Labels without a category, e.g. the
+// schedulable label, are kept before any other label.
+var labelCategoryPriority = []LabelCategory{
+	CPUModelCategory,
+	SEVCategory,
+	TopologyCategory,
+	RealtimeCategory,
+	HypervCategory,
+	DeviceCategory,
+	CPUTimerCategory,
+	CPUFeatureCategory,
+}
+
+// labelPriority returns the rank of the label within the label budget, lower ranks are kept first
+func labelPriority(label string) int {
+	category, ok := getLabelCategory(label)
+	if !ok {
+		return 0
+	}
+	for i, prioritized := range labelCategoryPriority {
+		if prioritized == category {
+			return i + 1
+		}
+	}
+	return len(labelCategoryPriority) + 1
+}
+
+// prefixedLabels are the labels whose node.kubevirt.io domain is replaced by the configured prefix
+var prefixedLabels = []string{
+	kubevirtv1.CPUModelLabel,
+	kubevirtv1.SupportedHostModelMigrationCPU,
+	kubevirtv1.HostModelCPULabel,
+	kubevirtv1.HostModelRequiredFeaturesLabel,
+	kubevirtv1.CPUFeatureLabel,
+	kubevirtv1.CPUModelVendorLabel,
+}
+
+type options struct {
+	prefix              string
+	categories          map[LabelCategory]bool
+	dryRun              bool
+	labelBudget         int
+	changeHistoryLength *int
+	trigger             <-chan struct{}
+	deniedFeatures      map[string]bool
+	modelProbe          ModelProbe
+	featureFamilies     FeatureFamilies
+	cpuInfoFlags        bool
+}
+
+// Option configures the node-labeller
+type Option func(*options) error
+
+// WithPrefix replaces the node.kubevirt.io domain of the cpu model, feature and vendor labels,
+// e.g. cpu-feature.example.com/ instead of cpu-feature.node.kubevirt.io/
+func WithPrefix(prefix string) Option {
+	return func(o *options) error {
+		if errs := validation.IsDNS1123Subdomain(prefix); len(errs) > 0 {
+			return fmt.Errorf("invalid label prefix %q: %s", prefix, strings.Join(errs, ", "))
+		}
+		o.prefix = prefix
+		return nil
+	}
+}
+
+// WithCategories restricts the emitted labels to the given categories
+func WithCategories(categories ...LabelCategory) Option {
+	return func(o *options) error {
+		if len(categories) == 0 {
+			return fmt.Errorf("at least one label category is required")
+		}
+		o.categories = make(map[LabelCategory]bool, len(categories))
+		for _, category := range categories {
+			if !isKnownCategory(category) {
+				return fmt.Errorf("unknown label category %q", category)
+			}
+			o.categories[category] = true
+		}
+		return nil
+	}
+}
+
+// WithDryRun computes and logs the label changes without patching the node
+func WithDryRun() Option {
+	return func(o *options) error {
+		o.dryRun = true
+		return nil
+	}
+}
+
+// WithLabelBudget limits the number of labels the node-labeller emits, 0 means unlimited
+func WithLabelBudget(budget int) Option {
+	return func(o *options) error {
+		if budget < 0 {
+			return fmt.Errorf("label budget must not be negative, got %d", budget)
+		}
+		o.labelBudget = budget
+		return nil
+	}
+}
+
+// WithChangeHistory sets the number of entries kept in the label change history annotation,
+// 0 disables the history
+func WithChangeHistory(length int) Option {
+	return func(o *options) error {
+		if length < 0 {
+			return fmt.Errorf("change history length must not be negative, got %d", length)
+		}
+		o.changeHistoryLength = &length
+		return nil
+	}
+}
+
+// WithTrigger forces a reconcile of the node whenever the trigger is signaled,
+// in addition to the periodic reconcile
+func WithTrigger(trigger <-chan struct{}) Option {
+	return func(o *options) error {
+		if trigger == nil {
+			return fmt.Errorf("trigger channel must not be nil")
+		}
+		o.trigger = trigger
+		return nil
+	}
+}
+
+// WithFeatureDenyList never advertises the given cpu features, even when the host supports them
+func WithFeatureDenyList(features ...string) Option {
+	return func(o *options) error {
+		o.deniedFeatures = make(map[string]bool, len(features))
+		for _, feature := range features {
+			if feature == "" {
+				return fmt.Errorf("denied cpu feature names must not be empty")
+			}
+			o.deniedFeatures[strings.ToLower(feature)] = true
+		}
+		return nil
+	}
+}
+
+// WithModelProbe only labels the cpu models for which the probe succeeds. The probe usually costs
+// a libvirt round-trip per model and pass, hence it is disabled by default.
+func WithModelProbe(probe ModelProbe) Option {
+	return func(o *options) error {
+		if probe == nil {
+			return fmt.Errorf("model probe must not be nil")
+		}
+		o.modelProbe = probe
+		return nil
+	}
+}
+
+// WithFeatureFamilies replaces the default cpu feature families by the given ones
+func WithFeatureFamilies(families FeatureFamilies) Option {
+	return func(o *options) error {
+		for family, features := range families {
+			if errs := validation.IsQualifiedName(family); len(errs) > 0 {
+				return fmt.Errorf("invalid cpu feature family %q: %s", family, strings.Join(errs, ", "))
+			}
+			if len(features) == 0 {
+				return fmt.Errorf("cpu feature family %q has no representative feature", family)
+			}
+		}
+		o.featureFamilies = families
+		return nil
+	}
+}
+
+// WithCPUInfoFlags labels the /proc/cpuinfo flags of the host which libvirt does not report as cpu features.
+// The kernel reports a lot of flags, hence they are not labelled by default.
+func WithCPUInfoFlags() Option {
+	return func(o *options) error {
+		o.cpuInfoFlags = true
+		return nil
+	}
+}
+
+func defaultOptions() options {
+	return options{
+		prefix:          defaultLabelPrefix,
+		featureFamilies: DefaultFeatureFamilies,
+	}
+}
+
+// applyOptions applies the given options on top of the defaults and rejects conflicting ones
+func applyOptions(opts ...Option) (options, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, err
+		}
+	}
+
+	if o.dryRun && o.changeHistoryLength != nil && *o.changeHistoryLength > 0 {
+		return o, fmt.Errorf("the label change history can not be recorded in dry-run mode")
+	}
+	return o, nil
+}
+
+func isKnownCategory(category LabelCategory) bool {
+	for _, known := range labelCategories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+func getLabelCategory(label string) (LabelCategory, bool) {
+	for prefix, category := range labelCategories {
+		if strings.HasPrefix(label, prefix) {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// withPrefix returns the label with the node.kubevirt.io domain replaced by prefix
+func withPrefix(label, prefix string) string {
+	if prefix == defaultLabelPrefix {
+		return label
+	}
+	for _, prefixedLabel := range prefixedLabels {
+		if strings.HasPrefix(label, prefixedLabel) {
+			name := strings.TrimSuffix(strings.TrimSuffix(prefixedLabel, "/"), defaultLabelPrefix)
+			return name + prefix + "/" + strings.TrimPrefix(label, prefixedLabel)
+		}
+	}
+	return label
+}
+
+// finalizeLabels filters the labels by category, applies the label prefix and drops the labels
+// exceeding the label budget, starting with the labels of the least valuable categories
+func (n *NodeLabeller) finalizeLabels(labels map[string]string) map[string]string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		if category, ok := getLabelCategory(key); ok && n.categories != nil && !n.categories[category] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if iPriority, jPriority := labelPriority(keys[i]), labelPriority(keys[j]); iPriority != jPriority {
+			return iPriority < jPriority
+		}
+		return keys[i] < keys[j]
+	})
+
+	if n.labelBudget > 0 && len(keys) > n.labelBudget {
+		dropped := keys[n.labelBudget:]
+		n.logger.Warningf("node-labeller drops %d labels exceeding the label budget of %d: %s",
+			len(dropped), n.labelBudget, strings.Join(dropped, ", "))
+		keys = keys[:n.labelBudget]
+	}
+
+	finalLabels := make(map[string]string, len(keys))
+	for _, key := range keys {
+		finalLabels[withPrefix(key, n.labelPrefix)] = labels[key]
+	}
+	return finalLabels
+}
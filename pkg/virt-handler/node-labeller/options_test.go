@@ -0,0 +1,165 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Node-labeller options", func() {
+
+	It("should default to the node.kubevirt.io prefix without any restriction", func() {
+		o, err := applyOptions()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(o.prefix).To(Equal(defaultLabelPrefix))
+		Expect(o.categories).To(BeNil())
+		Expect(o.dryRun).To(BeFalse())
+		Expect(o.labelBudget).To(BeZero())
+		Expect(o.changeHistoryLength).To(BeNil())
+		Expect(o.featureFamilies).To(Equal(DefaultFeatureFamilies))
+	})
+
+	It("should apply the given options", func() {
+		o, err := applyOptions(
+			WithPrefix("example.com"),
+			WithCategories(CPUModelCategory, CPUFeatureCategory),
+			WithLabelBudget(100),
+			WithChangeHistory(3),
+			WithFeatureDenyList("PDPE1GB"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(o.prefix).To(Equal("example.com"))
+		Expect(o.categories).To(Equal(map[LabelCategory]bool{CPUModelCategory: true, CPUFeatureCategory: true}))
+		Expect(o.labelBudget).To(Equal(100))
+		Expect(*o.changeHistoryLength).To(Equal(3))
+		Expect(o.deniedFeatures).To(Equal(map[string]bool{"pdpe1gb": true}))
+	})
+
+	DescribeTable("should reject invalid options", func(errorMessage string, opts ...Option) {
+		_, err := applyOptions(opts...)
+		Expect(err).To(MatchError(ContainSubstring(errorMessage)))
+	},
+		Entry("with an invalid prefix", "invalid label prefix", WithPrefix("Not_A_Domain")),
+		Entry("without categories", "at least one label category", WithCategories()),
+		Entry("with an unknown category", "unknown label category", WithCategories(CPUModelCategory, "gpu")),
+		Entry("with a negative label budget", "label budget must not be negative", WithLabelBudget(-1)),
+		Entry("with a negative change history length", "change history length must not be negative", WithChangeHistory(-1)),
+		Entry("with a nil trigger", "trigger channel must not be nil", WithTrigger(nil)),
+		Entry("with an invalid feature family", "invalid cpu feature family", WithFeatureFamilies(FeatureFamilies{"avx 512": {"avx512f"}})),
+		Entry("with an empty feature family", "has no representative feature", WithFeatureFamilies(FeatureFamilies{"avx512": {}})),
+		Entry("with a nil model probe", "model probe must not be nil", WithModelProbe(nil)),
+		Entry("with an empty denied feature", "denied cpu feature names must not be empty", WithFeatureDenyList("pdpe1gb", "")),
+		Entry("with a change history in dry-run mode", "can not be recorded in dry-run mode", WithDryRun(), WithChangeHistory(5)),
+	)
+
+	It("should allow to disable the change history in dry-run mode", func() {
+		o, err := applyOptions(WithDryRun(), WithChangeHistory(0))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(o.dryRun).To(BeTrue())
+	})
+
+	Context("finalizing the labels", func() {
+		var labels map[string]string
+
+		BeforeEach(func() {
+			labels = map[string]string{
+				kubevirtv1.CPUFeatureLabel + "vmx":   "true",
+				kubevirtv1.CPUModelLabel + "Penryn":  "true",
+				kubevirtv1.HypervLabel + "synic":     "true",
+				kubevirtv1.NUMATuningLabel:           "true",
+				kubevirtv1.CPUTimerLabel + "tsc-khz": "4008012000",
+			}
+		})
+
+		newFinalizer := func(opts ...Option) *NodeLabeller {
+			o, err := applyOptions(opts...)
+			Expect(err).ToNot(HaveOccurred())
+			return &NodeLabeller{
+				logger:      log.DefaultLogger(),
+				labelPrefix: o.prefix,
+				categories:  o.categories,
+				labelBudget: o.labelBudget,
+			}
+		}
+
+		It("should keep all labels by default", func() {
+			Expect(newFinalizer().finalizeLabels(labels)).To(Equal(labels))
+		})
+
+		It("should keep only the labels of the selected categories", func() {
+			Expect(newFinalizer(WithCategories(CPUModelCategory, TopologyCategory)).finalizeLabels(labels)).To(Equal(map[string]string{
+				kubevirtv1.CPUModelLabel + "Penryn": "true",
+				kubevirtv1.NUMATuningLabel:          "true",
+			}))
+		})
+
+		DescribeTable("should apply the prefix to the cpu model, feature and vendor labels", func(opts []Option, expectedLabels map[string]string) {
+			labels[kubevirtv1.CPUModelVendorLabel+"Intel"] = "true"
+			labels[kubevirtv1.HostModelCPULabel+"Skylake-Client-IBRS"] = "true"
+			labels[kubevirtv1.SupportedHostModelMigrationCPU+"Penryn"] = "true"
+			Expect(newFinalizer(opts...).finalizeLabels(labels)).To(Equal(expectedLabels))
+		},
+			Entry("with the default prefix", nil, map[string]string{
+				"cpu-feature.node.kubevirt.io/vmx":                    "true",
+				"cpu-model.node.kubevirt.io/Penryn":                   "true",
+				"cpu-model-migration.node.kubevirt.io/Penryn":         "true",
+				"host-model-cpu.node.kubevirt.io/Skylake-Client-IBRS": "true",
+				"cpu-vendor.node.kubevirt.io/Intel":                   "true",
+				kubevirtv1.HypervLabel + "synic":                      "true",
+				kubevirtv1.NUMATuningLabel:                            "true",
+				kubevirtv1.CPUTimerLabel + "tsc-khz":                  "4008012000",
+			}),
+			Entry("with a custom prefix", []Option{WithPrefix("example.com")}, map[string]string{
+				"cpu-feature.example.com/vmx":                    "true",
+				"cpu-model.example.com/Penryn":                   "true",
+				"cpu-model-migration.example.com/Penryn":         "true",
+				"host-model-cpu.example.com/Skylake-Client-IBRS": "true",
+				"cpu-vendor.example.com/Intel":                   "true",
+				kubevirtv1.HypervLabel + "synic":                 "true",
+				kubevirtv1.NUMATuningLabel:                       "true",
+				kubevirtv1.CPUTimerLabel + "tsc-khz":             "4008012000",
+			}),
+		)
+
+		It("should not exceed the label budget", func() {
+			Expect(newFinalizer(WithLabelBudget(2)).finalizeLabels(labels)).To(HaveLen(2))
+		})
+
+		It("should drop the labels of the least valuable categories first", func() {
+			Expect(newFinalizer(WithLabelBudget(3)).finalizeLabels(labels)).To(Equal(map[string]string{
+				kubevirtv1.CPUModelLabel + "Penryn": "true",
+				kubevirtv1.NUMATuningLabel:          "true",
+				kubevirtv1.HypervLabel + "synic":    "true",
+			}))
+		})
+
+		It("should recognize the prefixed labels as labeller labels", func() {
+			n := newFinalizer(WithPrefix("example.com"))
+			Expect(n.isPrefixedLabellerLabel("cpu-feature.example.com/vmx")).To(BeTrue())
+			Expect(n.isPrefixedLabellerLabel("cpu-model.example.com/Penryn")).To(BeTrue())
+			Expect(n.isPrefixedLabellerLabel("cpu-vendor.example.com/Intel")).To(BeTrue())
+			Expect(n.isPrefixedLabellerLabel("cpu-feature.other.com/vmx")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,65 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ndBusDevicesPath lists the devices of the libnvdimm bus, relative to the host root
+const ndBusDevicesPath = "sys/bus/nd/devices"
+
+// loadPersistentMemory sums up the capacity of the NVDIMM namespaces exposed by the host.
+// Hosts without the nd bus have no persistent memory.
+func (n *NodeLabeller) loadPersistentMemory() {
+	n.pmemCapacity = 0
+
+	entries, err := fs.ReadDir(n.hostFS, ndBusDevicesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	} else if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not list the nd bus devices")
+		return
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "namespace") {
+			continue
+		}
+		size, err := readUintFile(n.hostFS, path.Join(ndBusDevicesPath, entry.Name(), "size"))
+		if err != nil {
+			n.logger.Reason(err).Warningf("node-labeller could not read the size of nd namespace %s", entry.Name())
+			continue
+		}
+		n.pmemCapacity += int64(size)
+	}
+}
+
+func readUintFile(fsys fs.FS, name string) (uint64, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 63)
+}
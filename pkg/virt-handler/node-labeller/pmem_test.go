@@ -0,0 +1,53 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Persistent memory", func() {
+
+	DescribeTable("should sum up the capacity of the nd namespaces", func(hostFS fstest.MapFS, capacity int64) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), hostFS: hostFS}
+		n.loadPersistentMemory()
+		Expect(n.pmemCapacity).To(Equal(capacity))
+	},
+		Entry("with namespaces on the nd bus", fstest.MapFS{
+			"sys/bus/nd/devices/namespace0.0/size": {Data: []byte("8589934592\n")},
+			"sys/bus/nd/devices/namespace1.0/size": {Data: []byte("8589934592\n")},
+			"sys/bus/nd/devices/region0/size":      {Data: []byte("17179869184\n")},
+			"sys/bus/nd/devices/ndbus0/commands":   {Data: []byte("\n")},
+		}, int64(16*1024*1024*1024)),
+		Entry("with an unreadable namespace size", fstest.MapFS{
+			"sys/bus/nd/devices/namespace0.0/size": {Data: []byte("8589934592\n")},
+			"sys/bus/nd/devices/namespace1.0/size": {Data: []byte("unknown\n")},
+		}, int64(8*1024*1024*1024)),
+		Entry("with an nd bus without namespaces", fstest.MapFS{
+			"sys/bus/nd/devices/ndbus0/commands": {Data: []byte("\n")},
+		}, int64(0)),
+		Entry("without an nd bus", fstest.MapFS{}, int64(0)),
+	)
+})
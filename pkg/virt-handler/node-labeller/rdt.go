@@ -0,0 +1,80 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// resctrlInfoDir lists a directory per resource the resctrl filesystem can allocate, relative to the host root
+const resctrlInfoDir = "sys/fs/resctrl/info"
+
+// resctrlCATResources are the resctrl resources of the cache allocation technology, the CODE and DATA
+// variants replace the unified ones when code and data prioritization is enabled
+var resctrlCATResources = []string{"L3", "L3CODE", "L3DATA", "L2", "L2CODE", "L2DATA"}
+
+// resctrlMBAResource is the resctrl resource of the memory bandwidth allocation
+const resctrlMBAResource = "MB"
+
+// resctrlReader returns the resources the resctrl filesystem of the host can allocate, e.g. L3 and MB
+type resctrlReader func() ([]string, error)
+
+// readResctrlResources reads the allocatable resctrl resources, hosts without RDT support or without
+// the resctrl filesystem mounted have none
+func readResctrlResources(hostFS fs.FS) ([]string, error) {
+	if hostFS == nil {
+		return nil, fmt.Errorf("host filesystem is not available")
+	}
+	entries, err := fs.ReadDir(hostFS, resctrlInfoDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	resources := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			resources = append(resources, entry.Name())
+		}
+	}
+	return resources, nil
+}
+
+// addRDTLabels labels whether the host supports the Intel RDT cache allocation (CAT) and memory bandwidth
+// allocation (MBA). The resctrl filesystem can be mounted at runtime, hence it is read on every reconcile.
+func (n *NodeLabeller) addRDTLabels(labels *NodeLabels) {
+	if n.resctrlReader == nil {
+		return
+	}
+	resources, err := n.resctrlReader()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not read the resctrl resources of the host")
+		return
+	}
+
+	for _, resource := range resources {
+		for _, catResource := range resctrlCATResources {
+			labels.RDTCAT = labels.RDTCAT || resource == catResource
+		}
+		labels.RDTMBA = labels.RDTMBA || resource == resctrlMBAResource
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("RDT", func() {
+
+	catOnlyHost := fstest.MapFS{
+		"sys/fs/resctrl/info/L3/cbm_mask":         &fstest.MapFile{Data: []byte("7ff\n")},
+		"sys/fs/resctrl/info/L3/num_closids":      &fstest.MapFile{Data: []byte("16\n")},
+		"sys/fs/resctrl/info/L3_MON/mon_features": &fstest.MapFile{Data: []byte("llc_occupancy\n")},
+		"sys/fs/resctrl/info/last_cmd_status":     &fstest.MapFile{Data: []byte("ok\n")},
+	}
+
+	It("should read the resctrl resources of the host", func() {
+		resources, err := readResctrlResources(catOnlyHost)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf("L3", "L3_MON"))
+	})
+
+	It("should read no resources without the resctrl filesystem", func() {
+		resources, err := readResctrlResources(fstest.MapFS{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(BeEmpty())
+	})
+
+	DescribeTable("should label the RDT support of the resctrl reader", func(reader resctrlReader, expectedCAT, expectedMBA string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), resctrlReader: reader}
+		labels := &NodeLabels{}
+		n.addRDTLabels(labels)
+
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.RDTCATLabel, expectedCAT))
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.RDTMBALabel, expectedMBA))
+	},
+		Entry("on a host with CAT but not MBA", resctrlReader(func() ([]string, error) {
+			return readResctrlResources(catOnlyHost)
+		}), "true", "false"),
+		Entry("on a host with CAT and MBA", resctrlReader(func() ([]string, error) {
+			return []string{"L3", "MB", "L3_MON"}, nil
+		}), "true", "true"),
+		Entry("on a host with code and data prioritization", resctrlReader(func() ([]string, error) {
+			return []string{"L3CODE", "L3DATA"}, nil
+		}), "true", "false"),
+		Entry("on a host without RDT", resctrlReader(func() ([]string, error) {
+			return nil, nil
+		}), "false", "false"),
+		Entry("when the resources can not be read", resctrlReader(func() ([]string, error) {
+			return nil, fmt.Errorf("permission denied")
+		}), "false", "false"),
+	)
+})
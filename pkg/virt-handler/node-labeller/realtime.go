@@ -0,0 +1,60 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+	"strings"
+)
+
+const (
+	// kernelCmdlinePath is the path of the kernel command line, relative to the host root
+	kernelCmdlinePath = "proc/cmdline"
+	// isolatedCPUsPath lists the cpus isolated from the scheduler, relative to the host root
+	isolatedCPUsPath = "sys/devices/system/cpu/isolated"
+)
+
+// hasIsolatedCPUs checks if the host isolates cpus from the general scheduler, which real-time VMs require.
+// The isolated cpuset of the kernel is preferred, the isolcpus kernel argument is the fallback.
+func hasIsolatedCPUs(hostFS fs.FS) bool {
+	if hostFS == nil {
+		return false
+	}
+	if isolated, err := fs.ReadFile(hostFS, isolatedCPUsPath); err == nil && strings.TrimSpace(string(isolated)) != "" {
+		return true
+	}
+
+	cmdline, err := fs.ReadFile(hostFS, kernelCmdlinePath)
+	if err != nil {
+		return false
+	}
+	for _, arg := range strings.Fields(string(cmdline)) {
+		key, value, found := strings.Cut(arg, "=")
+		if found && key == "isolcpus" && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// addRealtimeCapableLabel labels the node as capable of running real-time VMs or not, the label is always set
+// so that VMs can select either state
+func (n *NodeLabeller) addRealtimeCapableLabel(labels *NodeLabels) {
+	labels.RealtimeCapable = hasIsolatedCPUs(n.hostFS)
+}
@@ -0,0 +1,66 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Real-time capability", func() {
+
+	cmdline := func(args string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(args + "\n")}
+	}
+
+	DescribeTable("should detect the isolated cpus", func(hostFS fstest.MapFS, expected bool) {
+		Expect(hasIsolatedCPUs(hostFS)).To(Equal(expected))
+	},
+		Entry("with an isolated cpuset", fstest.MapFS{
+			isolatedCPUsPath:  {Data: []byte("2-7\n")},
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz root=/dev/sda1"),
+		}, true),
+		Entry("with the isolcpus kernel argument", fstest.MapFS{
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz isolcpus=managed_irq,domain,2-7 nohz_full=2-7"),
+		}, true),
+		Entry("with an empty isolated cpuset", fstest.MapFS{
+			isolatedCPUsPath:  {Data: []byte("\n")},
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz root=/dev/sda1"),
+		}, false),
+		Entry("with an empty isolcpus kernel argument", fstest.MapFS{
+			kernelCmdlinePath: cmdline("BOOT_IMAGE=/vmlinuz isolcpus="),
+		}, false),
+		Entry("without the kernel command line", fstest.MapFS{}, false),
+	)
+
+	DescribeTable("should label the real-time capability explicitly", func(hostFS fstest.MapFS, expectedValue string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), hostFS: hostFS}
+		labels := &NodeLabels{}
+		n.addRealtimeCapableLabel(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.RealtimeCapableLabel, expectedValue))
+	},
+		Entry("when cpus are isolated", fstest.MapFS{kernelCmdlinePath: cmdline("isolcpus=2-7")}, "true"),
+		Entry("when no cpu is isolated", fstest.MapFS{kernelCmdlinePath: cmdline("root=/dev/sda1")}, "false"),
+	)
+})
@@ -0,0 +1,97 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var illegalLabelNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// sanitizeLabelName maps a cpu model or vendor name which is not a valid label name to a valid one.
+// Illegal characters are replaced with "_" and a hash of the original name is appended, so that
+// distinct names never map to the same label. Valid names are returned unchanged.
+func sanitizeLabelName(name string) string {
+	if len(validation.IsQualifiedName(name)) == 0 && !strings.Contains(name, "/") {
+		return name
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("%08x", hash.Sum32())
+
+	sanitized := strings.Trim(illegalLabelNameChars.ReplaceAllString(name, "_"), "._-")
+	if maxLength := validation.LabelValueMaxLength - len(suffix) - 1; len(sanitized) > maxLength {
+		sanitized = strings.TrimRight(sanitized[:maxLength], "._-")
+	}
+	if sanitized == "" {
+		return suffix
+	}
+	return sanitized + "-" + suffix
+}
+
+// sanitizeLabels sanitizes the name part of the label keys. It returns the sanitized labels and
+// the original names of the sanitized keys.
+func sanitizeLabels(labels map[string]string) (map[string]string, map[string]string) {
+	sanitizedLabels := make(map[string]string, len(labels))
+	originalNames := make(map[string]string)
+	for key, value := range labels {
+		prefix, name, found := strings.Cut(key, "/")
+		if !found {
+			sanitizedLabels[key] = value
+			continue
+		}
+		sanitizedName := sanitizeLabelName(name)
+		if sanitizedName != name {
+			originalNames[prefix+"/"+sanitizedName] = name
+		}
+		sanitizedLabels[prefix+"/"+sanitizedName] = value
+	}
+	return sanitizedLabels, originalNames
+}
+
+// setOriginalNamesAnnotation records the original names of the sanitized labels on the node,
+// the annotation is removed when no label had to be sanitized
+func setOriginalNamesAnnotation(node *v1.Node, originalNames map[string]string) {
+	if len(originalNames) == 0 {
+		delete(node.Annotations, kubevirtv1.LabellerOriginalNamesAnnotation)
+		return
+	}
+
+	raw, err := json.Marshal(originalNames)
+	if err != nil {
+		log.Log.Reason(err).Warning("node-labeller could not record the original names of the sanitized labels")
+		return
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.LabellerOriginalNamesAnnotation] = string(raw)
+}
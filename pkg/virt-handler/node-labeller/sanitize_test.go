@@ -0,0 +1,89 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("Label sanitization", func() {
+
+	DescribeTable("should map the model names to valid label names", func(name string, unchanged bool) {
+		sanitized := sanitizeLabelName(name)
+		Expect(validation.IsQualifiedName(kubevirtv1.CPUModelLabel + sanitized)).To(BeEmpty())
+		Expect(sanitizeLabelName(name)).To(Equal(sanitized), "sanitization should be deterministic")
+		if unchanged {
+			Expect(sanitized).To(Equal(name))
+		} else {
+			Expect(sanitized).ToNot(Equal(name))
+		}
+	},
+		Entry("with a valid model name", "Cascadelake-Server-noTSX", true),
+		Entry("with dots", "Opteron_G5.v2", true),
+		Entry("with a plus sign", "Skylake-Client+pcid", false),
+		Entry("with parentheses", "Intel(R) Xeon(R) CPU E5-2690", false),
+		Entry("with a slash", "qemu64/v1", false),
+		Entry("with a leading dash", "-EPYC", false),
+		Entry("with only illegal characters", "(+)", false),
+		Entry("with an empty name", "", false),
+		Entry("with a name too long for a label", strings.Repeat("Icelake-Server-", 5), false),
+	)
+
+	It("should keep the readable part of the name", func() {
+		Expect(sanitizeLabelName("Skylake-Client+pcid")).To(HavePrefix("Skylake-Client_pcid-"))
+	})
+
+	It("should not map distinct names to the same label name", func() {
+		Expect(sanitizeLabelName("Skylake(1)")).ToNot(Equal(sanitizeLabelName("Skylake+1)")))
+	})
+
+	It("should sanitize the label keys and keep the original names", func() {
+		labels, originalNames := sanitizeLabels(map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn":              "true",
+			kubevirtv1.CPUModelVendorLabel + "Vendor (Inc.)": "true",
+			kubevirtv1.NUMATuningLabel:                       "true",
+		})
+		sanitizedKey := kubevirtv1.CPUModelVendorLabel + sanitizeLabelName("Vendor (Inc.)")
+		Expect(labels).To(Equal(map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn": "true",
+			sanitizedKey:                        "true",
+			kubevirtv1.NUMATuningLabel:          "true",
+		}))
+		Expect(originalNames).To(Equal(map[string]string{sanitizedKey: "Vendor (Inc.)"}))
+	})
+
+	It("should record the original names on the node", func() {
+		node := &v1.Node{}
+		setOriginalNamesAnnotation(node, map[string]string{kubevirtv1.CPUModelLabel + "a_b-1234abcd": "a+b"})
+		Expect(node.Annotations).To(HaveKeyWithValue(kubevirtv1.LabellerOriginalNamesAnnotation,
+			`{"`+kubevirtv1.CPUModelLabel+`a_b-1234abcd":"a+b"}`))
+
+		setOriginalNamesAnnotation(node, map[string]string{})
+		Expect(node.Annotations).ToNot(HaveKey(kubevirtv1.LabellerOriginalNamesAnnotation))
+	})
+})
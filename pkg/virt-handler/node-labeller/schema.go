@@ -0,0 +1,108 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/kubevirt/pkg/virt-handler/node-labeller/util"
+)
+
+// legacyLabelMigrations maps the label prefixes of former node-labeller versions to the current ones
+var legacyLabelMigrations = map[string]string{
+	util.DeprecatedLabelNamespace + util.DeprecatedcpuModelPrefix:   kubevirtv1.CPUModelLabel,
+	util.DeprecatedLabelNamespace + util.DeprecatedcpuFeaturePrefix: kubevirtv1.CPUFeatureLabel,
+	util.DeprecatedLabelNamespace + util.DeprecatedHyperPrefix:      kubevirtv1.HypervLabel,
+}
+
+// migrateLegacyLabel returns the current key of a label in the format of a former node-labeller version
+func migrateLegacyLabel(label string) (string, bool) {
+	for legacyPrefix, prefix := range legacyLabelMigrations {
+		if strings.HasPrefix(label, legacyPrefix) {
+			return prefix + strings.TrimPrefix(label, legacyPrefix), true
+		}
+	}
+	return "", false
+}
+
+// labelDomain returns the domain of the label key, e.g. cpu-feature.node.kubevirt.io
+func labelDomain(label string) string {
+	domain, _, _ := strings.Cut(label, "/")
+	return domain
+}
+
+// isLabellerDomain reports whether the domain is owned by the node-labeller, i.e. whether it is the domain of one
+// of its current labels. Other domains, e.g. scheduling.node.kubevirt.io of virt-controller or the domain of the
+// legacy labels shared with node-feature-discovery, belong to other components.
+func (n *NodeLabeller) isLabellerDomain(domain string) bool {
+	for _, prefix := range nodeLabellerLabels {
+		if prefixDomain := labelDomain(prefix); strings.HasSuffix(prefixDomain, "."+defaultLabelPrefix) && prefixDomain == domain {
+			return true
+		}
+	}
+	if n.labelPrefix == defaultLabelPrefix {
+		return false
+	}
+	for _, prefix := range prefixedLabels {
+		if labelDomain(withPrefix(prefix, n.labelPrefix)) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnknownLabellerLabel reports whether the label is in a domain owned by the node-labeller,
+// but does not match any label of the current schema
+func (n *NodeLabeller) isUnknownLabellerLabel(label string) bool {
+	if !n.isLabellerDomain(labelDomain(label)) {
+		return false
+	}
+	if _, known := getLabelCategory(label); known {
+		return false
+	}
+	return !isNodeLabellerLabel(label) && !n.isPrefixedLabellerLabel(label)
+}
+
+// repairLabelDrift removes the labels which don't match the current schema from the node. Labels in the
+// format of a former node-labeller version are migrated if the node still has the capability they describe,
+// otherwise they are dropped together with the unknown labels. It reports whether the node was modified.
+func (n *NodeLabeller) repairLabelDrift(node *v1.Node, labels map[string]string) bool {
+	drifted := false
+	for label := range node.Labels {
+		if migratedLabel, legacy := migrateLegacyLabel(label); legacy {
+			if _, exists := labels[withPrefix(migratedLabel, n.labelPrefix)]; exists {
+				n.logger.Infof("node-labeller migrates label %s to %s on node %s", label, migratedLabel, node.Name)
+			} else {
+				n.logger.Infof("node-labeller drops stale label %s from node %s", label, node.Name)
+			}
+		} else if n.isUnknownLabellerLabel(label) {
+			n.logger.Infof("node-labeller drops label %s which does not match the current schema from node %s", label, node.Name)
+		} else {
+			continue
+		}
+		delete(node.Labels, label)
+		drifted = true
+	}
+	return drifted
+}
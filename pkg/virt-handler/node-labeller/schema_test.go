@@ -0,0 +1,88 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Label schema", func() {
+
+	DescribeTable("should migrate labels in a former format", func(label, expected string, legacy bool) {
+		migrated, isLegacy := migrateLegacyLabel(label)
+		Expect(isLegacy).To(Equal(legacy))
+		Expect(migrated).To(Equal(expected))
+	},
+		Entry("with a cpu model", "feature.node.kubernetes.io/cpu-model-Penryn", kubevirtv1.CPUModelLabel+"Penryn", true),
+		Entry("with a cpu feature", "feature.node.kubernetes.io/cpu-feature-vmx", kubevirtv1.CPUFeatureLabel+"vmx", true),
+		Entry("with a hyperv feature", "feature.node.kubernetes.io/kvm-info-cap-hyperv-synic", kubevirtv1.HypervLabel+"synic", true),
+		Entry("with a current label", kubevirtv1.CPUModelLabel+"Penryn", "", false),
+		Entry("with an unrelated label", "feature.node.kubernetes.io/pci-10de.present", "", false),
+	)
+
+	It("should drop the labels which do not match the current schema", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), labelPrefix: defaultLabelPrefix}
+		node := &k8sv1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"feature.node.kubernetes.io/cpu-model-Penryn": "true",
+					"feature.node.kubernetes.io/cpu-feature-svm":  "true",
+					"memory-hotplug.node.kubevirt.io/plug":        "true",
+					kubevirtv1.CPUModelLabel + "Penryn":           "true",
+					kubevirtv1.CPUSocketsLabel:                    "1",
+					"feature.node.kubernetes.io/pci-10de.present": "true",
+				},
+			},
+		}
+
+		Expect(n.repairLabelDrift(node, map[string]string{kubevirtv1.CPUModelLabel + "Penryn": "true"})).To(BeTrue())
+		Expect(node.Labels).To(Equal(map[string]string{
+			kubevirtv1.CPUModelLabel + "Penryn":           "true",
+			kubevirtv1.CPUSocketsLabel:                    "1",
+			"feature.node.kubernetes.io/pci-10de.present": "true",
+		}))
+		Expect(n.repairLabelDrift(node, map[string]string{})).To(BeFalse())
+	})
+
+	It("should keep the labels of other components under node.kubevirt.io", func() {
+		n := &NodeLabeller{logger: log.DefaultLogger(), labelPrefix: "example.com"}
+		labels := map[string]string{
+			"scheduling.node.kubevirt.io/tsc-frequency-2800000000": "true",
+			"node.kubevirt.io/other":                               "true",
+			"cpu-cache.node.kubevirt.io/l3":                        "true",
+			"cpu-model.example.com/Penryn":                         "true",
+		}
+		node := &k8sv1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+		for key, value := range labels {
+			node.Labels[key] = value
+		}
+		node.Labels["memory-hotplug.node.kubevirt.io/plug"] = "true"
+
+		Expect(n.repairLabelDrift(node, map[string]string{})).To(BeTrue())
+		Expect(node.Labels).To(Equal(labels))
+	})
+})
@@ -0,0 +1,54 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// capabilitiesProbe checks if the host capabilities reported by libvirt are still available
+type capabilitiesProbe func() error
+
+// hasDomCapabilities checks if the domain capabilities of libvirt can still be read
+func (n *NodeLabeller) hasDomCapabilities() error {
+	_, err := n.getDomCapabilities()
+	return err
+}
+
+// removeStaleLabels handles a node whose host capabilities became unavailable, e.g. because libvirt
+// can not be reached anymore. The labels derived from the capabilities are no longer trustworthy,
+// hence all the labeller labels are removed, which keeps VMs requiring cpu models, features or
+// devices off the node, and the node is annotated as stale. The labels are restored and the
+// annotation is removed by the first labelling pass after the capabilities are available again.
+func (n *NodeLabeller) removeStaleLabels(node *v1.Node, err error) {
+	n.logger.Reason(err).Warningf("node-labeller lost the host capabilities, removing the labels of node %s", node.Name)
+	node.Labels = mergeLabels(node.Labels, nil, n.isLabellerLabel)
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[kubevirtv1.CPULabelsStaleAnnotation] = "true"
+}
+
+// hasStaleLabels checks if the labels of the node were removed as stale
+func hasStaleLabels(node *v1.Node) bool {
+	_, exists := node.Annotations[kubevirtv1.CPULabelsStaleAnnotation]
+	return exists
+}
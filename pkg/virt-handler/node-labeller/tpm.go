@@ -0,0 +1,49 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+// tpmProbe checks if the host can emulate a TPM for the guests, which requires swtpm
+type tpmProbe func() (bool, error)
+
+// hasSwtpm checks the TPM capabilities of the hypervisor, libvirt only reports the emulator
+// backend when it finds the swtpm binary
+func (n *NodeLabeller) hasSwtpm() (bool, error) {
+	hostDomCapabilities, err := n.getDomCapabilities()
+	if err != nil {
+		return false, err
+	}
+	return hostDomCapabilities.Devices.TPM.SupportsEmulator(), nil
+}
+
+// loadTPMSupport records whether the host supports vTPM devices. A failing probe is logged and
+// the host is treated as not supporting them.
+func (n *NodeLabeller) loadTPMSupport() {
+	supported, err := n.tpmProbe()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not detect the vTPM support of the host")
+		supported = false
+	}
+	n.tpmSupported = supported
+}
+
+// addTPMLabel labels the node as supporting vTPM devices or not, the label is always set
+// so that VMs can select either state
+func (n *NodeLabeller) addTPMLabel(labels *NodeLabels) {
+	labels.TPM = n.tpmSupported
+}
@@ -0,0 +1,56 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("vTPM support", func() {
+
+	DescribeTable("should detect swtpm from the TPM capabilities", func(domCapabilities string, expected bool) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), volumePath: "testdata", domCapabilitiesFileName: domCapabilities}
+		supported, err := n.hasSwtpm()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(supported).To(Equal(expected))
+	},
+		Entry("with the emulator backend", "domcapabilities_tpm.xml", true),
+		Entry("without TPM capabilities", "domcapabilities_watchdog.xml", false),
+	)
+
+	DescribeTable("should label the vTPM support of the tpm probe", func(probe tpmProbe, expectedValue string) {
+		n := &NodeLabeller{logger: log.DefaultLogger(), tpmProbe: probe}
+		n.loadTPMSupport()
+		labels := &NodeLabels{}
+		n.addTPMLabel(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.TPMLabel, expectedValue))
+	},
+		Entry("when swtpm is available", tpmProbe(func() (bool, error) { return true, nil }), "true"),
+		Entry("when swtpm is missing", tpmProbe(func() (bool, error) { return false, nil }), "false"),
+		Entry("when the probe fails", tpmProbe(func() (bool, error) {
+			return true, fmt.Errorf("domain capabilities are not available")
+		}), "false"),
+	)
+})
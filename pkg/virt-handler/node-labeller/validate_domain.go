@@ -0,0 +1,103 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+
+	v1 "kubevirt.io/api/core/v1"
+)
+
+// ValidateDomain checks whether a domain with the given machine type, cpu model and cpu features can run on the host
+// and returns an aggregated error describing every unsatisfied requirement. Empty machine types and models are not
+// checked. Features are given as in the VMI spec, e.g. "+vmx" or "-mpx", and are unsatisfiable when the host-model
+// cpu disables them, since the domain capabilities don't list the other features of the host.
+func (h HostDomCapabilities) ValidateDomain(machine, model string, features []string) error {
+	var errs []error
+
+	if machine != "" {
+		if _, ok := h.SupportsMachine(machine); !ok {
+			errs = append(errs, fmt.Errorf("machine type %s is not supported by the host, supported machine types are: %s",
+				machine, strings.Join(h.Machines, ", ")))
+		}
+	}
+
+	if model != "" {
+		if err := h.validateModel(model); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	disabled := h.disabledHostModelFeatures()
+	for _, feature := range features {
+		if strings.HasPrefix(feature, "-") {
+			continue
+		}
+		name := strings.TrimPrefix(feature, "+")
+		if disabled[name] {
+			errs = append(errs, fmt.Errorf("cpu feature %s is not supported by the host cpu, remove it or select a node supporting it", name))
+		}
+	}
+
+	return errors.NewAggregate(errs)
+}
+
+// validateModel checks that the cpu model, including the host-model and host-passthrough modes, is usable on the host
+func (h HostDomCapabilities) validateModel(model string) error {
+	for _, mode := range h.CPU.Mode {
+		switch {
+		case model == v1.CPUModeHostModel && mode.Name == v1.CPUModeHostModel,
+			model == v1.CPUModeHostPassthrough && mode.Name == v1.CPUModeHostPassthrough:
+			if mode.Supported == "no" {
+				return fmt.Errorf("cpu mode %s is not supported by the host", model)
+			}
+			return nil
+		case mode.Name == "custom":
+			for _, m := range mode.Model {
+				if m.Name != model {
+					continue
+				}
+				if m.Usable != "yes" {
+					return fmt.Errorf("cpu model %s is not usable on the host, use an older cpu model or select another node", model)
+				}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("cpu model %s is unknown to the host", model)
+}
+
+// disabledHostModelFeatures returns the features the host-model cpu disables because the host lacks them
+func (h HostDomCapabilities) disabledHostModelFeatures() map[string]bool {
+	disabled := make(map[string]bool)
+	for _, mode := range h.CPU.Mode {
+		if mode.Name != v1.CPUModeHostModel {
+			continue
+		}
+		for _, feature := range mode.Feature {
+			if feature.Policy == "disable" {
+				disabled[feature.Name] = true
+			}
+		}
+	}
+	return disabled
+}
@@ -0,0 +1,62 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Domain validation", func() {
+
+	var domCapabilities HostDomCapabilities
+
+	BeforeEach(func() {
+		domCapabilities = loadDomCapabilitiesFixture("domcapabilities_intel.xml")
+	})
+
+	DescribeTable("should accept a domain the host can run", func(machine, model string, features []string) {
+		Expect(domCapabilities.ValidateDomain(machine, model, features)).To(Succeed())
+	},
+		Entry("with a usable model", "q35", "Penryn", []string{"+vmx"}),
+		Entry("with the explicit machine type", "pc-q35-rhel9.2.0", "Skylake-Server-noTSX-IBRS", nil),
+		Entry("with the host-model", "q35", "host-model", []string{"-mpx"}),
+		Entry("with the host-passthrough", "", "host-passthrough", nil),
+		Entry("without any requirement", "", "", nil),
+	)
+
+	DescribeTable("should describe every unsatisfied requirement", func(machine, model string, features []string, expectedErrors ...string) {
+		err := domCapabilities.ValidateDomain(machine, model, features)
+		Expect(err).To(HaveOccurred())
+		for _, expectedError := range expectedErrors {
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		}
+	},
+		Entry("with an unsupported machine type", "pc-i440fx-8.0", "Penryn", nil,
+			"machine type pc-i440fx-8.0 is not supported by the host, supported machine types are: pc-q35-rhel9.2.0"),
+		Entry("with an unusable model", "q35", "Icelake-Server", nil,
+			"cpu model Icelake-Server is not usable on the host"),
+		Entry("with an unknown model", "q35", "EPYC-Genoa", nil,
+			"cpu model EPYC-Genoa is unknown to the host"),
+		Entry("with an unsupported feature", "q35", "Penryn", []string{"+vmx", "hle", "+rtm"},
+			"cpu feature hle is not supported by the host cpu", "cpu feature rtm is not supported by the host cpu"),
+		Entry("on all axes at once", "virt", "EPYC", []string{"+mpx"},
+			"machine type virt is not supported", "cpu model EPYC is not usable", "cpu feature mpx is not supported"),
+	)
+})
@@ -0,0 +1,102 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// virshVersionFile holds the output of `virsh version`, written by the node-labeller init container
+const virshVersionFile = "virsh_version.txt"
+
+// versionSource returns the libvirt and QEMU versions of the host
+type versionSource func() (libvirtVersion, qemuVersion string, err error)
+
+// loadVersions records the libvirt and QEMU versions of the host. The versions only help debugging,
+// hence a failing discovery is logged without blocking the labelling.
+func (n *NodeLabeller) loadVersions() {
+	libvirtVersion, qemuVersion, err := n.versionSource()
+	if err != nil {
+		n.logger.Reason(err).Warning("node-labeller could not discover the libvirt and QEMU versions")
+		libvirtVersion, qemuVersion = "", ""
+	}
+	n.libvirtVersion = libvirtVersion
+	n.qemuVersion = qemuVersion
+}
+
+// readVirshVersion parses the libvirt and QEMU versions from the `virsh version` output, e.g.
+//
+//	Using library: libvirt 9.0.0
+//	Running hypervisor: QEMU 7.2.0
+func readVirshVersion(volumePath string) (libvirtVersion, qemuVersion string, err error) {
+	content, err := os.ReadFile(filepath.Join(volumePath, virshVersionFile))
+	if err != nil {
+		return "", "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Using library":
+			libvirtVersion = fields[1]
+		case "Running hypervisor":
+			qemuVersion = fields[1]
+		}
+	}
+	if libvirtVersion == "" || qemuVersion == "" {
+		return "", "", fmt.Errorf("%s does not contain the libvirt and QEMU versions", virshVersionFile)
+	}
+	return libvirtVersion, qemuVersion, nil
+}
+
+// setVersionAnnotations records the libvirt and QEMU versions on the node, the annotations are
+// omitted when the versions are unknown
+func (n *NodeLabeller) setVersionAnnotations(node *v1.Node) {
+	versions := map[string]string{
+		kubevirtv1.LibvirtVersionAnnotation: n.libvirtVersion,
+		kubevirtv1.QEMUVersionAnnotation:    n.qemuVersion,
+	}
+	for annotation, version := range versions {
+		if version == "" {
+			delete(node.Annotations, annotation)
+			continue
+		}
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		node.Annotations[annotation] = version
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/log"
+)
+
+var _ = Describe("Libvirt and QEMU versions", func() {
+
+	It("should read the versions from the virsh version output", func() {
+		libvirtVersion, qemuVersion, err := readVirshVersion("testdata")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(libvirtVersion).To(Equal("9.0.0"))
+		Expect(qemuVersion).To(Equal("7.2.0"))
+	})
+
+	It("should fail without the hypervisor version", func() {
+		volumePath := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(volumePath, virshVersionFile), []byte("Using library: libvirt 9.0.0\n"), 0644)).To(Succeed())
+		_, _, err := readVirshVersion(volumePath)
+		Expect(err).To(MatchError(ContainSubstring("does not contain the libvirt and QEMU versions")))
+	})
+
+	It("should annotate the node with the versions of the version source", func() {
+		n := &NodeLabeller{
+			logger: log.DefaultLogger(),
+			versionSource: func() (string, string, error) {
+				return "9.5.0", "8.0.0", nil
+			},
+		}
+		n.loadVersions()
+		node := &v1.Node{}
+		n.setVersionAnnotations(node)
+		Expect(node.Annotations).To(Equal(map[string]string{
+			kubevirtv1.LibvirtVersionAnnotation: "9.5.0",
+			kubevirtv1.QEMUVersionAnnotation:    "8.0.0",
+		}))
+	})
+
+	It("should omit the annotations when the version discovery fails", func() {
+		n := &NodeLabeller{
+			logger: log.DefaultLogger(),
+			versionSource: func() (string, string, error) {
+				return "", "", fmt.Errorf("virsh version is not available")
+			},
+		}
+		n.loadVersions()
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			kubevirtv1.LibvirtVersionAnnotation: "9.0.0",
+			kubevirtv1.QEMUVersionAnnotation:    "7.2.0",
+		}}}
+		n.setVersionAnnotations(node)
+		Expect(node.Annotations).To(BeEmpty())
+	})
+})
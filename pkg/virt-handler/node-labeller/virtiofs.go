@@ -0,0 +1,59 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"io/fs"
+)
+
+// virtiofsdPaths are the locations of the virtiofsd binary on the usual distributions, relative to the host root
+var virtiofsdPaths = []string{
+	"usr/libexec/virtiofsd",
+	"usr/lib/qemu/virtiofsd",
+	"usr/bin/virtiofsd",
+}
+
+// binaryProbe checks if an executable exists at the given path
+type binaryProbe func(path string) bool
+
+// hasExecutable checks if the path below the host root is an executable file
+func (n *NodeLabeller) hasExecutable(binaryPath string) bool {
+	if n.hostFS == nil {
+		return false
+	}
+	info, err := fs.Stat(n.hostFS, binaryPath)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// loadVirtiofsSupport records whether virtiofsd, which shares filesystems with the guests, is available
+func (n *NodeLabeller) loadVirtiofsSupport() {
+	n.virtiofsSupported = false
+	for _, virtiofsdPath := range virtiofsdPaths {
+		if n.binaryProbe(virtiofsdPath) {
+			n.virtiofsSupported = true
+			return
+		}
+	}
+}
+
+// addVirtiofsLabel labels the node as supporting virtio-fs or not, the label is always set
+// so that VMs can select either state
+func (n *NodeLabeller) addVirtiofsLabel(labels *NodeLabels) {
+	labels.Virtiofs = n.virtiofsSupported
+}
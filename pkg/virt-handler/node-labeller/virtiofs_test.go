@@ -0,0 +1,67 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+package nodelabeller
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+var _ = Describe("virtio-fs support", func() {
+
+	withBinaries := func(binaries ...string) binaryProbe {
+		return func(binaryPath string) bool {
+			for _, binary := range binaries {
+				if binaryPath == binary {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	DescribeTable("should label the virtio-fs support of the binary probe", func(probe binaryProbe, expectedValue string) {
+		n := &NodeLabeller{binaryProbe: probe}
+		n.loadVirtiofsSupport()
+		labels := &NodeLabels{}
+		n.addVirtiofsLabel(labels)
+		Expect(labels.ToMap()).To(HaveKeyWithValue(kubevirtv1.VirtiofsLabel, expectedValue))
+	},
+		Entry("with virtiofsd in libexec", withBinaries("usr/libexec/virtiofsd"), "true"),
+		Entry("with virtiofsd shipped by qemu", withBinaries("usr/lib/qemu/virtiofsd"), "true"),
+		Entry("with virtiofsd in bin", withBinaries("usr/bin/virtiofsd"), "true"),
+		Entry("without virtiofsd", withBinaries("usr/bin/qemu-kvm"), "false"),
+	)
+
+	It("should only find executable files below the host root", func() {
+		n := &NodeLabeller{hostFS: fstest.MapFS{
+			"usr/libexec/virtiofsd":  &fstest.MapFile{Mode: 0755},
+			"usr/bin/virtiofsd":      &fstest.MapFile{Mode: 0644},
+			"usr/lib/qemu/virtiofsd": &fstest.MapFile{},
+		}}
+		Expect(n.hasExecutable("usr/libexec/virtiofsd")).To(BeTrue())
+		Expect(n.hasExecutable("usr/bin/virtiofsd")).To(BeFalse())
+		Expect(n.hasExecutable("usr/lib/qemu")).To(BeFalse())
+		Expect(n.hasExecutable("usr/sbin/virtiofsd")).To(BeFalse())
+	})
+})
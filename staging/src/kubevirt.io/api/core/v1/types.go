@@ -881,6 +881,104 @@ const (
 	// This label represents the host model required features
 	HostModelRequiredFeaturesLabel = "host-model-required-features.node.kubevirt.io/"
 	NodeHostModelIsObsoleteLabel   = "node-labeller.kubevirt.io/obsolete-host-model"
+	// This label represents the disk aio modes (native, threads, io_uring) supported on the node
+	DiskAIOLabel = "disk-aio.node.kubevirt.io/"
+	// This label represents whether guest memory can be bound to host NUMA nodes
+	NUMATuningLabel = "numa-tuning.node.kubevirt.io/supported"
+	// This label represents whether the virtio-iommu device is supported on the node
+	VirtIOIOMMULabel = "virtio-iommu.node.kubevirt.io/supported"
+	// This label represents the number of CPU sockets of the node
+	CPUSocketsLabel = "cpu-sockets.node.kubevirt.io"
+	// This label represents the number of CPU cores per socket of the node
+	CPUCoresPerSocketLabel = "cpu-cores-per-socket.node.kubevirt.io"
+	// This label represents the number of CPU threads per core of the node
+	CPUThreadsPerCoreLabel = "cpu-threads-per-core.node.kubevirt.io"
+	// This label represents whether memory can be hot-unplugged from guests on the node
+	MemoryHotUnplugLabel = "memory-hotplug.node.kubevirt.io/unplug-supported"
+	// This label represents the GIC versions (v2, v3) supported on arm64 nodes
+	GICVersionLabel = "gic-version.node.kubevirt.io/"
+	// This label represents whether persistent memory (NVDIMM) is available on the node
+	PMEMAvailableLabel = "pmem.node.kubevirt.io/available"
+	// This label represents the persistent memory capacity of the node
+	PMEMCapacityLabel = "pmem.node.kubevirt.io/capacity"
+	// This label represents whether 3D accelerated graphics through virgl are supported on the node
+	VirglLabel = "gpu.node.kubevirt.io/virgl"
+	// This label represents the microcode revision of the CPU of the node
+	CPUMicrocodeLabel = "cpu-microcode.node.kubevirt.io"
+	// This label represents the number of CPU models usable on the node
+	CPUModelCountLabel = "cpu-model-count.node.kubevirt.io"
+	// This label represents the guest watchdog actions (reset, poweroff, pause, ...) supported on the node
+	WatchdogActionLabel = "watchdog-action.node.kubevirt.io/"
+	// This label is set when KVM is available, at least one CPU model is usable and the node-labeller pass computing it did not fail
+	NodeLabellerSchedulableLabel = "node.kubevirt.io/schedulable"
+	// These labels represent the size in KiB of a single L1 (data), L2 and L3 cache of the node
+	CPUL1CacheLabel = "cpu-l1-cache-kib.node.kubevirt.io"
+	CPUL2CacheLabel = "cpu-l2-cache-kib.node.kubevirt.io"
+	CPUL3CacheLabel = "cpu-l3-cache-kib.node.kubevirt.io"
+	// This label represents whether the node has isolated cpus to run real-time VMs, it is either true or false
+	RealtimeCapableLabel = "realtime-capable.node.kubevirt.io"
+	// This label marks nodes on which libvirt reports no usable cpu model, e.g. because of a misconfigured host
+	NoUsableCPUModelLabel = "no-usable-cpu-model.node.kubevirt.io"
+	// This label represents a cpu feature family, e.g. avx512, of which the node supports a representative feature
+	CPUFeatureFamilyLabel = "cpu-feature-family.node.kubevirt.io/"
+	// This label represents whether the node has an active iommu for VFIO passthrough, it is either true or false
+	IOMMULabel = "iommu.node.kubevirt.io"
+	// These labels represent the hugepage sizes for which the node has a nonzero pool
+	Hugepages2MiLabel = "hugepages-2Mi.node.kubevirt.io"
+	Hugepages1GiLabel = "hugepages-1Gi.node.kubevirt.io"
+	// This label represents whether the node can emulate a TPM through swtpm, it is either true or false
+	TPMLabel = "tpm.node.kubevirt.io"
+	// This label prefix represents the cpu flags reported by /proc/cpuinfo but not by libvirt, e.g. constant_tsc
+	CPUInfoFlagLabel = "cpuinfo-flag.node.kubevirt.io/"
+	// This label represents whether the node supports the kvm-hint-dedicated enlightenment, it is either true or false
+	KVMHintDedicatedLabel = "kvm-hint-dedicated.node.kubevirt.io"
+	// This label represents whether the node has the EFI roms to boot VMs with UEFI, it is either true or false
+	UEFILabel = "uefi.node.kubevirt.io"
+	// This label represents whether the node has the EFI roms to boot VMs with UEFI secure boot, it is either true or false
+	SecureBootLabel = "secure-boot.node.kubevirt.io"
+	// This label prefix represents the scaling governor of the node cpus, e.g. performance, or mixed if they differ
+	CPUGovernorLabel = "cpu-governor.node.kubevirt.io/"
+	// This label represents whether virtiofsd is available on the node to share filesystems with VMs, it is either true or false
+	VirtiofsLabel = "virtiofs.node.kubevirt.io"
+	// This label prefix represents the number of assignable devices of a permitted host device resource, e.g. 2.
+	// The "/" of the resource name is replaced by ".", e.g. devices.node.kubevirt.io/nvidia.com.TU104GL_Tesla_T4
+	DeviceCountLabel = "devices.node.kubevirt.io/"
+	// This label represents whether the node can present a custom vendor_id to VMs, it is either true or false
+	CPUVendorIDSpoofLabel = "cpu-vendor-id-spoof.node.kubevirt.io"
+	// This label represents the maximum number of vCPUs libvirt allows for a single VM on the node
+	MaxVCPULabel = "max-vcpu.node.kubevirt.io"
+	// This label represents whether the host has multiple NUMA nodes a guest NUMA topology can be mapped to, it is either true or false
+	NUMALabel = "numa.node.kubevirt.io"
+	// This label represents the number of NUMA nodes of the host
+	NUMANodesLabel = "numa-nodes.node.kubevirt.io"
+	// This label represents whether all the cpu vulnerabilities known to the kernel of the node are mitigated, it is either true or false
+	CPUVulnMitigatedLabel = "cpu-vuln-mitigated.node.kubevirt.io"
+	// This label represents whether vCPUs can be hotplugged to running VMs on the node, it is either true or false
+	CPUHotplugLabel = "cpu-hotplug.node.kubevirt.io"
+	// This label represents whether the node supports the Intel RDT cache allocation technology, it is either true or false
+	RDTCATLabel = "rdt-cat.node.kubevirt.io"
+	// This label represents whether the node supports the Intel RDT memory bandwidth allocation, it is either true or false
+	RDTMBALabel = "rdt-mba.node.kubevirt.io"
+	// This annotation holds a bounded history of the label changes made by the node-labeller
+	LabellerChangeHistoryAnnotation = "node-labeller.kubevirt.io/change-history"
+	// This annotation maps the node-labeller labels whose names had to be sanitized to the original names
+	LabellerOriginalNamesAnnotation = "node-labeller.kubevirt.io/original-names"
+	// This annotation represents the number of usable cpu models out of all the cpu models known to libvirt, e.g. 12/24
+	CPUModelUsabilityAnnotation = "cpu-model-usability.node.kubevirt.io"
+	// This annotation holds a hash of the cpu models, cpu features and machine types of the node, nodes with
+	// equivalent capabilities share the same value
+	CPUFingerprintAnnotation = "cpu-fingerprint.node.kubevirt.io"
+	// This annotation marks a node whose node-labeller labels were removed because the host capabilities became unavailable
+	CPULabelsStaleAnnotation = "cpu-labels-stale.node.kubevirt.io"
+	// This annotation prefix holds the mitigation status the kernel reports for a cpu vulnerability, e.g. cpu-vuln.node.kubevirt.io/mds
+	CPUVulnerabilityAnnotation = "cpu-vuln.node.kubevirt.io/"
+	// This annotation lists the cpu models which libvirt reports usable but which failed the define probe
+	LabellerRejectedModelsAnnotation = "node-labeller.kubevirt.io/rejected-models"
+	// These annotations represent the libvirt and QEMU versions the node-labeller labels were discovered with
+	LibvirtVersionAnnotation = "libvirt-version.node.kubevirt.io"
+	QEMUVersionAnnotation    = "qemu-version.node.kubevirt.io"
+	// This annotation lists the cpu features of the node which migration targets have to support as well, e.g. AMX
+	MigrationSensitiveFeaturesAnnotation = "node-labeller.kubevirt.io/migration-sensitive-features"
 
 	LabellerSkipNodeAnnotation        = "node-labeller.kubevirt.io/skip-node"
 	VirtualMachineLabel               = AppLabel + "/vm"
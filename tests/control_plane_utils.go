@@ -0,0 +1,364 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package tests
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8sv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"kubevirt.io/client-go/kubecli"
+
+	"kubevirt.io/kubevirt/tests/flags"
+	"kubevirt.io/kubevirt/tests/framework/controlplane"
+	"kubevirt.io/kubevirt/tests/libnode"
+)
+
+// FilterRunningReadyPods returns copies of the running and ready pods whose name starts with one of
+// the given prefixes. When node names are passed, only the pods scheduled to these nodes are returned.
+func FilterRunningReadyPods(podList *k8sv1.PodList, podPrefixes []string, nodeNames ...string) []*k8sv1.Pod {
+	pods := make([]*k8sv1.Pod, 0)
+	for _, pod := range podList.Items {
+		if !isRunningAndReady(&pod) {
+			continue
+		}
+
+		for _, podPrefix := range podPrefixes {
+			if !strings.HasPrefix(pod.Name, podPrefix) {
+				continue
+			}
+			if len(nodeNames) == 0 {
+				pods = append(pods, pod.DeepCopy())
+				continue
+			}
+			for _, nodeName := range nodeNames {
+				if pod.Spec.NodeName == nodeName {
+					pods = append(pods, pod.DeepCopy())
+				}
+			}
+		}
+	}
+	return pods
+}
+
+func isRunningAndReady(pod *k8sv1.Pod) bool {
+	if pod.Status.Phase != k8sv1.PodRunning {
+		return false
+	}
+	if status, reason := PodReadyWithReason(pod); status != k8sv1.ConditionTrue {
+		GinkgoWriter.Printf("Skipping pod %s which is not ready (%s)\n", pod.Name, reason)
+		return false
+	}
+	return true
+}
+
+// EvictionResult holds the outcome of the eviction of a single pod, Err is nil if the eviction succeeded
+type EvictionResult struct {
+	PodName string
+	Err     error
+}
+
+// EvictPodsMatchingSelector evicts the running and ready pods matching the label selector one after the other
+// and returns the result of every eviction. Unlike matching name prefixes, this keeps working when pod names change.
+func EvictPodsMatchingSelector(virtCli kubecli.KubevirtClient, namespace string, selector labels.Selector) []EvictionResult {
+	podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	results := make([]EvictionResult, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		if !isRunningAndReady(&pod) {
+			continue
+		}
+		err := virtCli.CoreV1().Pods(namespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+		results = append(results, EvictionResult{PodName: pod.Name, Err: err})
+	}
+	return results
+}
+
+// EvictAllButAssertLast evicts all running and ready pods whose name starts with podPrefix and asserts
+// that every eviction but the last one succeeds. The error of the last eviction is returned, so that
+// callers can assert whether, and with which status, it was rejected by the pod disruption budget.
+func EvictAllButAssertLast(virtCli kubecli.KubevirtClient, namespace, podPrefix string) error {
+	podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	runningPods := FilterRunningReadyPods(podList, []string{podPrefix})
+	ExpectWithOffset(1, runningPods).ToNot(BeEmpty(), "no running and ready pods with prefix %s", podPrefix)
+
+	for _, pod := range runningPods[:len(runningPods)-1] {
+		err = virtCli.CoreV1().Pods(namespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+		ExpectWithOffset(1, err).ToNot(HaveOccurred(), "failed to evict pod %s", pod.Name)
+	}
+
+	lastPod := runningPods[len(runningPods)-1]
+	return virtCli.CoreV1().Pods(namespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: lastPod.Name}})
+}
+
+// EvictionStatusCode returns the HTTP status code of a failed eviction, e.g. 429 when the eviction would violate a
+// pod disruption budget. It returns 0 if the error does not carry an API status.
+func EvictionStatusCode(err error) int32 {
+	var status errors.APIStatus
+	if goerrors.As(err, &status) {
+		return status.Status().Code
+	}
+	return 0
+}
+
+// ExpectEvictionRejectedByPDB asserts that the eviction was rejected with 429 TooManyRequests, a retry-after hint and
+// the disruption budget cause, which is how the API server signals that a pod disruption budget does not allow the
+// eviction. Any other error, e.g. a missing pod or a forbidden request, fails the assertion.
+func ExpectEvictionRejectedByPDB(err error) {
+	ExpectWithOffset(1, err).To(HaveOccurred(), "the eviction should be rejected by the pod disruption budget")
+	ExpectWithOffset(1, EvictionStatusCode(err)).To(Equal(int32(http.StatusTooManyRequests)),
+		"the eviction should fail with TooManyRequests, but failed with: %v", err)
+	_, hasRetryAfter := errors.SuggestsClientDelay(err)
+	ExpectWithOffset(1, hasRetryAfter).To(BeTrue(), "the rejected eviction should suggest a retry-after delay: %v", err)
+	ExpectWithOffset(1, errors.HasStatusCause(err, policyv1.DisruptionBudgetCause)).To(BeTrue(),
+		"the eviction should be rejected by the disruption budget: %v", err)
+}
+
+// PDBMinAvailableAbsolute returns the number of pods the pod disruption budget keeps available out of totalReplicas.
+// Like the disruption controller, percentages are rounded up and a maxUnavailable budget is converted to the
+// replicas which have to stay available.
+func PDBMinAvailableAbsolute(virtCli kubecli.KubevirtClient, namespace, pdbName string, totalReplicas int32) (int32, error) {
+	pdb, err := virtCli.PolicyV1().PodDisruptionBudgets(namespace).Get(context.Background(), pdbName, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case pdb.Spec.MinAvailable != nil:
+		minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, int(totalReplicas), true)
+		if err != nil {
+			return 0, fmt.Errorf("invalid minAvailable of pod disruption budget %s: %v", pdbName, err)
+		}
+		return int32(minAvailable), nil
+	case pdb.Spec.MaxUnavailable != nil:
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, int(totalReplicas), true)
+		if err != nil {
+			return 0, fmt.Errorf("invalid maxUnavailable of pod disruption budget %s: %v", pdbName, err)
+		}
+		if minAvailable := totalReplicas - int32(maxUnavailable); minAvailable > 0 {
+			return minAvailable, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("pod disruption budget %s sets neither minAvailable nor maxUnavailable", pdbName)
+	}
+}
+
+// GetLeaderPodName returns the name of the running pod holding the given leader election lease. The holder
+// identity is either the pod name or, as set up by some components, the pod name followed by "_<uuid>".
+func GetLeaderPodName(virtCli kubecli.KubevirtClient, namespace, leaseName string) (string, error) {
+	lease, err := virtCli.CoordinationV1().Leases(namespace).Get(context.Background(), leaseName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return "", fmt.Errorf("lease %s/%s has no holder", namespace, leaseName)
+	}
+	holderIdentity := *lease.Spec.HolderIdentity
+
+	candidates := []string{holderIdentity}
+	if podName, _, found := strings.Cut(holderIdentity, "_"); found {
+		candidates = append(candidates, podName)
+	}
+	for _, podName := range candidates {
+		pod, err := virtCli.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if pod.Status.Phase != k8sv1.PodRunning {
+			return "", fmt.Errorf("pod %s holding lease %s/%s is not running but %s", podName, namespace, leaseName, pod.Status.Phase)
+		}
+		return podName, nil
+	}
+	return "", fmt.Errorf("holder identity %q of lease %s/%s does not match any pod", holderIdentity, namespace, leaseName)
+}
+
+// AssertPodAntiAffinitySpread asserts that the running pods of the deployment occupy distinct nodes, which its pod
+// anti-affinity is expected to achieve as long as there are at least as many schedulable nodes as pods. Otherwise the
+// check is skipped. The failure message reports which pods run on which node.
+func AssertPodAntiAffinitySpread(virtCli kubecli.KubevirtClient, namespace, deploymentName string) {
+	deployment, err := virtCli.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	podsPerNode := map[string][]string{}
+	runningPods := 0
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != k8sv1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		runningPods++
+		podsPerNode[pod.Spec.NodeName] = append(podsPerNode[pod.Spec.NodeName], pod.Name)
+	}
+	ExpectWithOffset(1, runningPods).ToNot(BeZero(), "no running pods of deployment %s found", deploymentName)
+
+	if schedulableNodes := len(libnode.GetAllSchedulableNodes(virtCli).Items); schedulableNodes < runningPods {
+		By(fmt.Sprintf("Skipping the spread check of deployment %s, its %d pods can not spread across %d schedulable nodes",
+			deploymentName, runningPods, schedulableNodes))
+		return
+	}
+	ExpectWithOffset(1, podsPerNode).To(HaveLen(runningPods),
+		"pods of deployment %s share nodes, the pods per node are: %s", deploymentName, formatPodsPerNode(podsPerNode))
+}
+
+// formatPodsPerNode formats the pod names per node sorted by node, e.g. "node01: [virt-api-1 virt-api-2], node02: [virt-api-3]"
+func formatPodsPerNode(podsPerNode map[string][]string) string {
+	nodeNames := make([]string, 0, len(podsPerNode))
+	for nodeName := range podsPerNode {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+
+	distribution := make([]string, 0, len(nodeNames))
+	for _, nodeName := range nodeNames {
+		podNames := append([]string{}, podsPerNode[nodeName]...)
+		sort.Strings(podNames)
+		distribution = append(distribution, fmt.Sprintf("%s: %v", nodeName, podNames))
+	}
+	return strings.Join(distribution, ", ")
+}
+
+// SnapshotDeployment captures the spec of the deployment, so that RestoreDeployment can undo whatever a test changes on it.
+// The snapshot logic lives in the controlplane framework package, where it is covered by unit tests against a fake client.
+func SnapshotDeployment(virtCli kubecli.KubevirtClient, namespace, name string) *controlplane.DeploymentSnapshot {
+	snapshot, err := controlplane.SnapshotDeployment(virtCli, namespace, name)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred(), "failed to snapshot deployment %s", name)
+	return snapshot
+}
+
+// RestoreDeployment writes the spec captured by SnapshotDeployment back to the deployment and waits until virt-operator,
+// which reconciles its deployments concurrently, and the deployment controller have settled on the snapshot replicas
+func RestoreDeployment(virtCli kubecli.KubevirtClient, snapshot *controlplane.DeploymentSnapshot) {
+	ExpectWithOffset(1, controlplane.RestoreDeployment(virtCli, snapshot)).To(Succeed(), "failed to restore deployment %s", snapshot.Name)
+	ExpectWithOffset(1, WaitForDeploymentReplicas(virtCli, snapshot.Namespace, snapshot.Name, *snapshot.Spec.Replicas,
+		time.Duration(flags.StabilizationTimeoutInSeconds)*time.Second)).To(Succeed())
+}
+
+// AssertNoReplicaOvershoot watches the pods of the deployment for the given window, e.g. while it recovers from
+// evictions, and fails as soon as more running pods than the spec replicas plus a single surge pod are observed
+func AssertNoReplicaOvershoot(virtCli kubecli.KubevirtClient, namespace, deploymentName string, window time.Duration) {
+	ExpectWithOffset(1, controlplane.CheckReplicaOvershoot(virtCli, namespace, deploymentName, window)).To(Succeed())
+}
+
+// WaitForDeploymentReplicas waits until the updated, available and ready replicas of the deployment all equal want.
+// On timeout the error contains the last observed deployment status.
+func WaitForDeploymentReplicas(virtCli kubecli.KubevirtClient, namespace, name string, want int32, timeout time.Duration) error {
+	var lastStatus *appsv1.DeploymentStatus
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		deployment, err := virtCli.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		lastStatus = deployment.Status.DeepCopy()
+		return deployment.Status.UpdatedReplicas == want &&
+			deployment.Status.AvailableReplicas == want &&
+			deployment.Status.ReadyReplicas == want, nil
+	})
+	if err != nil {
+		if lastStatus == nil {
+			return fmt.Errorf("failed to wait for %d replicas of deployment %s: %v", want, name, err)
+		}
+		return fmt.Errorf("deployment %s did not reach %d replicas, last observed %d updated, %d available and %d ready replicas: %v",
+			name, want, lastStatus.UpdatedReplicas, lastStatus.AvailableReplicas, lastStatus.ReadyReplicas, err)
+	}
+	return nil
+}
+
+// AssertContainersNonRoot asserts that the containers of the running pods whose name starts with podPrefix
+// enforce runAsNonRoot, either through their own security context or through the pod security context
+func AssertContainersNonRoot(virtCli kubecli.KubevirtClient, namespace, podPrefix string) {
+	podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	pods := FilterRunningReadyPods(podList, []string{podPrefix})
+	ExpectWithOffset(1, pods).ToNot(BeEmpty(), "no running pods with prefix %s found", podPrefix)
+
+	var rootContainers []string
+	for _, pod := range pods {
+		podRunAsNonRoot := pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsNonRoot != nil && *pod.Spec.SecurityContext.RunAsNonRoot
+		containers := append([]k8sv1.Container{}, pod.Spec.InitContainers...)
+		for _, container := range append(containers, pod.Spec.Containers...) {
+			runAsNonRoot := podRunAsNonRoot
+			if container.SecurityContext != nil && container.SecurityContext.RunAsNonRoot != nil {
+				runAsNonRoot = *container.SecurityContext.RunAsNonRoot
+			}
+			if !runAsNonRoot {
+				rootContainers = append(rootContainers, fmt.Sprintf("%s/%s", pod.Name, container.Name))
+			}
+		}
+	}
+	ExpectWithOffset(1, rootContainers).To(BeEmpty(), "containers do not enforce runAsNonRoot")
+}
+
+const (
+	// a wedged container should be restarted within a few minutes, longer periods or more tolerated
+	// failures than these leave the control plane unavailable for too long
+	maxLivenessPeriodSeconds    = int32(60)
+	maxLivenessFailureThreshold = int32(10)
+)
+
+// AssertContainersHaveLivenessProbe asserts that the containers of the running pods whose name starts with
+// podPrefix define a liveness probe with a sane period and failure threshold, and reports every container which does not
+func AssertContainersHaveLivenessProbe(virtCli kubecli.KubevirtClient, namespace, podPrefix string) {
+	podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	pods := FilterRunningReadyPods(podList, []string{podPrefix})
+	ExpectWithOffset(1, pods).ToNot(BeEmpty(), "no running pods with prefix %s found", podPrefix)
+
+	var invalidProbes []string
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			probe := container.LivenessProbe
+			switch {
+			case probe == nil:
+				invalidProbes = append(invalidProbes, fmt.Sprintf("%s/%s: no liveness probe", pod.Name, container.Name))
+			case probe.PeriodSeconds < 1 || probe.PeriodSeconds > maxLivenessPeriodSeconds:
+				invalidProbes = append(invalidProbes, fmt.Sprintf("%s/%s: liveness probe period of %ds", pod.Name, container.Name, probe.PeriodSeconds))
+			case probe.FailureThreshold < 1 || probe.FailureThreshold > maxLivenessFailureThreshold:
+				invalidProbes = append(invalidProbes, fmt.Sprintf("%s/%s: liveness probe failure threshold of %d", pod.Name, container.Name, probe.FailureThreshold))
+			}
+		}
+	}
+	ExpectWithOffset(1, invalidProbes).To(BeEmpty(), "containers are missing a sane liveness probe")
+}
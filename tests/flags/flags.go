@@ -63,6 +63,19 @@ var MigrationNetworkNIC = "eth1"
 
 var DisableCustomSELinuxPolicy bool
 
+var CleanupNamespaces = ""
+var CleanupNodeSelector = ""
+
+const (
+	DefaultStabilizationTimeoutInSeconds = 300
+	DefaultPollIntervalInSeconds         = 3
+	DefaultRescheduleSLAInSeconds        = 120
+)
+
+var StabilizationTimeoutInSeconds = DefaultStabilizationTimeoutInSeconds
+var PollIntervalInSeconds = DefaultPollIntervalInSeconds
+var RescheduleSLAInSeconds = DefaultRescheduleSLAInSeconds
+
 func init() {
 	kubecli.Init()
 	flag.StringVar(&KubeVirtUtilityVersionTag, "utility-container-tag", "", "Set the image tag or digest to use")
@@ -98,6 +111,11 @@ func init() {
 	flag.StringVar(&DNSServiceNamespace, "dns-service-namespace", "kube-system", "cluster DNS service namespace")
 	flag.StringVar(&MigrationNetworkNIC, "migration-network-nic", "eth1", "NIC to use on cluster nodes to access the dedicated migration network")
 	flag.BoolVar(&DisableCustomSELinuxPolicy, "disable-custom-selinux-policy", false, "disables the installation and use of the custom SELinux policy for virt-launcher")
+	flag.StringVar(&CleanupNamespaces, "cleanup-namespaces", "", "Comma separated list of test namespaces cleaned after each test, all test namespaces are cleaned if empty")
+	flag.StringVar(&CleanupNodeSelector, "cleanup-node-selector", "", "Label selector of the nodes cleaned after each test, all schedulable nodes are cleaned if empty")
+	flag.IntVar(&StabilizationTimeoutInSeconds, "stabilization-timeout", DefaultStabilizationTimeoutInSeconds, "Seconds the control plane resilience tests wait for the control plane to stabilize")
+	flag.IntVar(&PollIntervalInSeconds, "poll-interval", DefaultPollIntervalInSeconds, "Seconds between two checks of the control plane resilience tests")
+	flag.IntVar(&RescheduleSLAInSeconds, "reschedule-sla", DefaultRescheduleSLAInSeconds, "Seconds within which control plane pods deleted from a failed node have to be ready on another node")
 }
 
 func NormalizeFlags() {
@@ -118,4 +136,17 @@ func NormalizeFlags() {
 		PreviousUtilityTag = PreviousReleaseTag
 	}
 
+	// Zero or negative timeouts would make the polling tests fail or spin, fall back to the defaults
+	if StabilizationTimeoutInSeconds <= 0 {
+		StabilizationTimeoutInSeconds = DefaultStabilizationTimeoutInSeconds
+	}
+
+	if PollIntervalInSeconds <= 0 {
+		PollIntervalInSeconds = DefaultPollIntervalInSeconds
+	}
+
+	if RescheduleSLAInSeconds <= 0 {
+		RescheduleSLAInSeconds = DefaultRescheduleSLAInSeconds
+	}
+
 }
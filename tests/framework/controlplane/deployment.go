@@ -0,0 +1,70 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package controlplane
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"kubevirt.io/client-go/kubecli"
+)
+
+// DeploymentSnapshot is the spec of a deployment captured before a test mutates it
+type DeploymentSnapshot struct {
+	Namespace string
+	Name      string
+	Spec      appsv1.DeploymentSpec
+}
+
+// SnapshotDeployment captures the spec of the deployment, so that RestoreDeployment can undo
+// whatever a test changes on it, e.g. its replicas or the node selector of its pods
+func SnapshotDeployment(virtCli kubecli.KubevirtClient, namespace, name string) (*DeploymentSnapshot, error) {
+	deployment, err := virtCli.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &DeploymentSnapshot{
+		Namespace: namespace,
+		Name:      name,
+		Spec:      *deployment.Spec.DeepCopy(),
+	}, nil
+}
+
+// RestoreDeployment writes the captured spec back to the deployment, retrying on conflicts.
+// A deployment whose spec still matches the snapshot is left untouched, so that no rollout is triggered.
+func RestoreDeployment(virtCli kubecli.KubevirtClient, snapshot *DeploymentSnapshot) error {
+	deploymentsClient := virtCli.AppsV1().Deployments(snapshot.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := deploymentsClient.Get(context.Background(), snapshot.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(deployment.Spec, snapshot.Spec) {
+			return nil
+		}
+		deployment.Spec = *snapshot.Spec.DeepCopy()
+		_, err = deploymentsClient.Update(context.Background(), deployment, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,123 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package controlplane
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+
+	"kubevirt.io/client-go/kubecli"
+)
+
+var _ = Describe("Deployment snapshots", func() {
+	const (
+		namespace      = "kubevirt"
+		deploymentName = "virt-controller"
+	)
+
+	var virtCli *kubecli.MockKubevirtClient
+	var kubeClient *fake.Clientset
+
+	countUpdates := func() int {
+		updates := 0
+		for _, action := range kubeClient.Actions() {
+			if action.GetVerb() == "update" {
+				updates++
+			}
+		}
+		return updates
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		deployment, err := kubeClient.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return deployment
+	}
+
+	BeforeEach(func() {
+		virtCli, kubeClient = newFakeClient(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(2),
+				Template: k8sv1.PodTemplateSpec{
+					Spec: k8sv1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/os": "linux"}},
+				},
+			},
+		})
+	})
+
+	It("should restore the spec a test mutated", func() {
+		snapshot, err := SnapshotDeployment(virtCli, namespace, deploymentName)
+		Expect(err).ToNot(HaveOccurred())
+
+		deployment := getDeployment()
+		deployment.Spec.Replicas = pointer.Int32(1)
+		deployment.Spec.Template.Spec.NodeSelector = map[string]string{k8sv1.LabelHostname: "node01"}
+		_, err = kubeClient.AppsV1().Deployments(namespace).Update(context.Background(), deployment, metav1.UpdateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(RestoreDeployment(virtCli, snapshot)).To(Succeed())
+		Expect(getDeployment().Spec).To(Equal(snapshot.Spec))
+	})
+
+	It("should not update a deployment whose spec did not change", func() {
+		snapshot, err := SnapshotDeployment(virtCli, namespace, deploymentName)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(RestoreDeployment(virtCli, snapshot)).To(Succeed())
+		Expect(countUpdates()).To(BeZero())
+	})
+
+	It("should retry the restore on conflicts", func() {
+		snapshot, err := SnapshotDeployment(virtCli, namespace, deploymentName)
+		Expect(err).ToNot(HaveOccurred())
+		snapshot.Spec.Replicas = pointer.Int32(3)
+
+		conflicts := 1
+		kubeClient.Fake.PrependReactor("update", "deployments", func(action testing.Action) (handled bool, obj runtime.Object, err error) {
+			if conflicts == 0 {
+				return false, nil, nil
+			}
+			conflicts--
+			return true, nil, errors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, deploymentName, nil)
+		})
+
+		Expect(RestoreDeployment(virtCli, snapshot)).To(Succeed())
+		Expect(countUpdates()).To(Equal(2))
+		Expect(getDeployment().Spec.Replicas).To(HaveValue(BeEquivalentTo(3)))
+	})
+
+	It("should fail to snapshot a missing deployment", func() {
+		_, err := SnapshotDeployment(virtCli, namespace, "virt-missing")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
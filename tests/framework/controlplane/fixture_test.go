@@ -0,0 +1,40 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package controlplane
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubevirt.io/client-go/kubecli"
+)
+
+// newFakeClient returns a KubeVirt client serving the given objects from a fake clientset,
+// together with the clientset to inspect and manipulate them
+func newFakeClient(objects ...runtime.Object) (*kubecli.MockKubevirtClient, *fake.Clientset) {
+	kubeClient := fake.NewSimpleClientset(objects...)
+	virtCli := kubecli.NewMockKubevirtClient(gomock.NewController(GinkgoT()))
+	virtCli.EXPECT().AppsV1().Return(kubeClient.AppsV1()).AnyTimes()
+	virtCli.EXPECT().CoreV1().Return(kubeClient.CoreV1()).AnyTimes()
+	return virtCli, kubeClient
+}
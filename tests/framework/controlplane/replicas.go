@@ -0,0 +1,69 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"kubevirt.io/client-go/kubecli"
+)
+
+// CheckReplicaOvershoot watches the pods of the deployment for the given window, e.g. while it recovers from
+// evictions, and returns an error as soon as more running pods than the spec replicas plus a single surge pod are observed.
+// Terminating pods are not counted, since their replacements are expected to be running alongside them.
+func CheckReplicaOvershoot(virtCli kubecli.KubevirtClient, namespace, deploymentName string, window time.Duration) error {
+	deployment, err := virtCli.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	maxRunning := int(*deployment.Spec.Replicas) + 1
+
+	err = wait.PollImmediate(time.Second, window, func() (bool, error) {
+		podList, err := virtCli.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return false, err
+		}
+		running := 0
+		for _, pod := range podList.Items {
+			if pod.Status.Phase == k8sv1.PodRunning && pod.DeletionTimestamp == nil {
+				running++
+			}
+		}
+		if running > maxRunning {
+			return false, fmt.Errorf("deployment %s runs %d pods, more than its %d replicas plus a single surge pod",
+				deploymentName, running, *deployment.Spec.Replicas)
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil
+	}
+	return err
+}
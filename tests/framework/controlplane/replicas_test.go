@@ -0,0 +1,87 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package controlplane
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"kubevirt.io/client-go/kubecli"
+)
+
+var _ = Describe("Replica overshoot", func() {
+	const (
+		namespace      = "kubevirt"
+		deploymentName = "virt-api"
+		window         = 1500 * time.Millisecond
+	)
+
+	var virtCli *kubecli.MockKubevirtClient
+
+	newPod := func(name string, phase k8sv1.PodPhase, terminating bool) *k8sv1.Pod {
+		pod := &k8sv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"kubevirt.io": deploymentName}},
+			Status:     k8sv1.PodStatus{Phase: phase},
+		}
+		if terminating {
+			pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}
+		return pod
+	}
+
+	newClient := func(pods ...runtime.Object) {
+		virtCli, _ = newFakeClient(append(pods, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(2),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubevirt.io": deploymentName}},
+			},
+		})...)
+	}
+
+	It("should tolerate a single surge pod and terminating pods", func() {
+		newClient(
+			newPod("virt-api-1", k8sv1.PodRunning, false),
+			newPod("virt-api-2", k8sv1.PodRunning, false),
+			newPod("virt-api-3", k8sv1.PodRunning, false),
+			newPod("virt-api-4", k8sv1.PodRunning, true),
+			newPod("virt-api-5", k8sv1.PodPending, false),
+		)
+		Expect(CheckReplicaOvershoot(virtCli, namespace, deploymentName, window)).To(Succeed())
+	})
+
+	It("should fail when more pods than a single surge pod are running", func() {
+		newClient(
+			newPod("virt-api-1", k8sv1.PodRunning, false),
+			newPod("virt-api-2", k8sv1.PodRunning, false),
+			newPod("virt-api-3", k8sv1.PodRunning, false),
+			newPod("virt-api-4", k8sv1.PodRunning, false),
+		)
+		Expect(CheckReplicaOvershoot(virtCli, namespace, deploymentName, window)).To(MatchError(ContainSubstring("deployment virt-api runs 4 pods")))
+	})
+})
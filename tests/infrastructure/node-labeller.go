@@ -67,6 +67,12 @@ var _ = DescribeInfra("Node-labeller", func() {
 		if len(nodesWithKVM) == 0 {
 			Skip("Skip testing with node-labeller, because there are no nodes with kvm")
 		}
+
+		// the node-labeller may not have finished its first reconcile yet
+		for _, node := range nodesWithKVM {
+			Expect(tests.WaitForNodeCPULabels(virtClient, node.Name, 30*time.Second)).To(Succeed())
+		}
+		nodesWithKVM = libnode.GetNodesWithKVM()
 	})
 
 	AfterEach(func() {
@@ -36,6 +36,7 @@ import (
 	k8sv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 
@@ -54,11 +55,23 @@ import (
 var SchedulableNode = ""
 
 func CleanNodes() {
+	CleanNodesMatching("")
+}
+
+// CleanNodesMatching removes the test taints and labels from the schedulable nodes matching the
+// label selector, an empty selector matches all of them
+func CleanNodesMatching(labelSelector string) {
 	virtCli := kubevirt.Client()
 
+	selector, err := labels.Parse(labelSelector)
+	Expect(err).ToNot(HaveOccurred(), "invalid node label selector %q", labelSelector)
+
 	clusterDrainKey := GetNodeDrainKey()
 
 	for _, node := range GetAllSchedulableNodes(virtCli).Items {
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
 		old, err := json.Marshal(node)
 		Expect(err).ToNot(HaveOccurred())
 		new := node.DeepCopy()
@@ -283,14 +296,59 @@ func GetNodesWithKVM() []*k8sv1.Node {
 }
 
 // GetAllSchedulableNodes returns list of Nodes which are "KubeVirt" schedulable.
-func GetAllSchedulableNodes(virtClient kubecli.KubevirtClient) *k8sv1.NodeList {
+// When architectures are passed, only the nodes labeled with one of them are returned.
+func GetAllSchedulableNodes(virtClient kubecli.KubevirtClient, architectures ...string) *k8sv1.NodeList {
+	labelSelector := v1.NodeSchedulable + "=" + "true"
+	if len(architectures) > 0 {
+		labelSelector += fmt.Sprintf(",%s in (%s)", k8sv1.LabelArchStable, strings.Join(architectures, ","))
+	}
 	nodes, err := virtClient.CoreV1().Nodes().List(context.Background(), k8smetav1.ListOptions{
-		LabelSelector: v1.NodeSchedulable + "=" + "true",
+		LabelSelector: labelSelector,
 	})
 	Expect(err).ToNot(HaveOccurred(), "Should list compute nodes")
 	return nodes
 }
 
+// SchedulableNodesCache memoizes the result of GetAllSchedulableNodes for a limited time.
+// It is meant to be created per spec, by callers polling the schedulable nodes in tight loops
+// which can tolerate a slightly outdated node list.
+type SchedulableNodesCache struct {
+	virtClient kubecli.KubevirtClient
+	ttl        time.Duration
+	entries    map[string]schedulableNodesEntry
+}
+
+type schedulableNodesEntry struct {
+	nodes     *k8sv1.NodeList
+	fetchedAt time.Time
+}
+
+// NewSchedulableNodesCache returns a cache keeping the schedulable nodes for the given ttl
+func NewSchedulableNodesCache(virtClient kubecli.KubevirtClient, ttl time.Duration) *SchedulableNodesCache {
+	return &SchedulableNodesCache{
+		virtClient: virtClient,
+		ttl:        ttl,
+		entries:    map[string]schedulableNodesEntry{},
+	}
+}
+
+// GetAllSchedulableNodes returns the cached schedulable nodes of the given architectures,
+// listing them again once the ttl expired
+func (c *SchedulableNodesCache) GetAllSchedulableNodes(architectures ...string) *k8sv1.NodeList {
+	key := strings.Join(architectures, ",")
+	if entry, ok := c.entries[key]; ok && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.nodes.DeepCopy()
+	}
+	nodes := GetAllSchedulableNodes(c.virtClient, architectures...)
+	c.entries[key] = schedulableNodesEntry{nodes: nodes, fetchedAt: time.Now()}
+	return nodes.DeepCopy()
+}
+
+// Invalidate drops the cached node lists, e.g. after changing the schedulability of a node
+func (c *SchedulableNodesCache) Invalidate() {
+	c.entries = map[string]schedulableNodesEntry{}
+}
+
 func GetHighestCPUNumberAmongNodes(virtClient kubecli.KubevirtClient) int {
 	var cpus int64
 
@@ -70,7 +70,12 @@ func (IgnoreDeprecationWarningsLogger) HandleWarningHeader(code int, agent strin
 	}
 }
 
-func CleanNamespaces() {
+// CleanNamespaces removes the test resources from the given test namespaces, or from all of them if none are given
+func CleanNamespaces(namespaces ...string) {
+	if len(namespaces) == 0 {
+		namespaces = TestNamespaces
+	}
+
 	// Replace the warning handler with a custom one that ignores certain deprecation warnings from KubeVirt
 	restConfig, err := kubecli.GetKubevirtClientConfig()
 	util.PanicOnError(err)
@@ -80,7 +85,7 @@ func CleanNamespaces() {
 	virtCli, err := kubecli.GetKubevirtClientFromRESTConfig(restConfig)
 	util.PanicOnError(err)
 
-	for _, namespace := range TestNamespaces {
+	for _, namespace := range namespaces {
 		listOptions := metav1.ListOptions{
 			LabelSelector: cleanup.TestLabelForNamespace(namespace),
 		}
@@ -62,6 +62,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
@@ -114,10 +115,42 @@ const (
 	defaultDiskSize        = "1Gi"
 )
 
-func TestCleanup() {
+// CleanupOption scopes the cleanup done by TestCleanup
+type CleanupOption func(*cleanupScope)
+
+type cleanupScope struct {
+	namespaces   []string
+	nodeSelector string
+}
+
+// WithCleanupNamespaces restricts the cleanup to the given test namespaces
+func WithCleanupNamespaces(namespaces ...string) CleanupOption {
+	return func(s *cleanupScope) {
+		s.namespaces = namespaces
+	}
+}
+
+// WithCleanupNodeSelector restricts the node cleanup to the nodes matching the label selector
+func WithCleanupNodeSelector(labelSelector string) CleanupOption {
+	return func(s *cleanupScope) {
+		s.nodeSelector = labelSelector
+	}
+}
+
+// TestCleanup cleans all test namespaces and nodes, unless the cleanup is scoped by the
+// cleanup-namespaces and cleanup-node-selector flags or by the given options
+func TestCleanup(opts ...CleanupOption) {
+	scope := &cleanupScope{nodeSelector: flags.CleanupNodeSelector}
+	if flags.CleanupNamespaces != "" {
+		scope.namespaces = strings.Split(flags.CleanupNamespaces, ",")
+	}
+	for _, opt := range opts {
+		opt(scope)
+	}
+
 	GinkgoWriter.Println("Global test cleanup started.")
-	testsuite.CleanNamespaces()
-	libnode.CleanNodes()
+	testsuite.CleanNamespaces(scope.namespaces...)
+	libnode.CleanNodesMatching(scope.nodeSelector)
 	resetToDefaultConfig()
 	testsuite.EnsureKubevirtReady()
 	SetupAlpineHostPath()
@@ -480,6 +513,23 @@ func GetRunningPodByLabel(label string, labelType string, namespace string, node
 	return readyPod, nil
 }
 
+// PodReady returns the status of the pod's Ready condition
+func PodReady(pod *k8sv1.Pod) k8sv1.ConditionStatus {
+	status, _ := PodReadyWithReason(pod)
+	return status
+}
+
+// PodReadyWithReason returns the status of the pod's Ready condition together with
+// its reason and message, so that callers can explain why a pod is not ready
+func PodReadyWithReason(pod *k8sv1.Pod) (k8sv1.ConditionStatus, string) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == k8sv1.PodReady {
+			return cond.Status, fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+		}
+	}
+	return k8sv1.ConditionUnknown, "pod has no Ready condition"
+}
+
 func GetComputeContainerOfPod(pod *k8sv1.Pod) *k8sv1.Container {
 	return GetContainerOfPod(pod, "compute")
 }
@@ -2287,6 +2337,32 @@ func GetNodeHostModel(node *k8sv1.Node) (hostModel string) {
 	return hostModel
 }
 
+// WaitForNodeCPULabels waits until the node-labeller labelled at least one cpu model on the node.
+// On timeout the error contains the number of labels last observed on the node.
+func WaitForNodeCPULabels(virtCli kubecli.KubevirtClient, nodeName string, timeout time.Duration) error {
+	observedLabels := -1
+	err := wait.PollImmediate(time.Second, timeout, func() (bool, error) {
+		node, err := virtCli.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		observedLabels = len(node.Labels)
+		for key := range node.Labels {
+			if strings.HasPrefix(key, v1.CPUModelLabel) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		if observedLabels < 0 {
+			return fmt.Errorf("failed to wait for the cpu model labels of node %s: %v", nodeName, err)
+		}
+		return fmt.Errorf("node %s has no cpu model label, last observed %d labels: %v", nodeName, observedLabels, err)
+	}
+	return nil
+}
+
 func dvSizeBySourceURL(url string) string {
 	if url == cd.DataVolumeImportUrlForContainerDisk(cd.ContainerDiskFedoraTestTooling) ||
 		url == cd.DataVolumeImportUrlForContainerDisk(cd.ContainerDiskFedoraRealtime) {
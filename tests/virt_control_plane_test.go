@@ -23,15 +23,24 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"kubevirt.io/kubevirt/tests/decorators"
 
 	v1 "k8s.io/api/apps/v1"
 	k8sv1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -39,21 +48,22 @@ import (
 	k6sv1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/client-go/kubecli"
 
+	"kubevirt.io/kubevirt/pkg/virt-controller/leaderelectionconfig"
 	"kubevirt.io/kubevirt/tests"
 	"kubevirt.io/kubevirt/tests/exec"
 	"kubevirt.io/kubevirt/tests/flags"
 	"kubevirt.io/kubevirt/tests/framework/checks"
+	"kubevirt.io/kubevirt/tests/framework/controlplane"
 	"kubevirt.io/kubevirt/tests/framework/kubevirt"
-	"kubevirt.io/kubevirt/tests/framework/matcher"
 	"kubevirt.io/kubevirt/tests/libnode"
 	"kubevirt.io/kubevirt/tests/testsuite"
 	"kubevirt.io/kubevirt/tests/util"
 )
 
-const (
-	DefaultStabilizationTimeoutInSeconds = 300
-	DefaultPollIntervalInSeconds         = 3
-)
+const schedulableNodesCacheTTL = 30 * time.Second
+
+// recoveryWindow is how long a deployment is watched for excess pods while it recovers from evictions
+const recoveryWindow = 30 * time.Second
 
 const (
 	multiReplica  = true
@@ -63,79 +73,79 @@ const (
 var _ = Describe("[Serial][ref_id:2717][sig-compute]KubeVirt control plane resilience", Serial, decorators.SigCompute, func() {
 
 	var virtCli kubecli.KubevirtClient
+	var schedulableNodes *libnode.SchedulableNodesCache
 
 	RegisterFailHandler(Fail)
 
 	controlPlaneDeploymentNames := []string{"virt-api", "virt-controller"}
 
+	var deploymentSnapshots []*controlplane.DeploymentSnapshot
+
 	BeforeEach(func() {
 		virtCli = kubevirt.Client()
+		schedulableNodes = libnode.NewSchedulableNodesCache(virtCli, schedulableNodesCacheTTL)
+		deploymentSnapshots = nil
+		for _, deploymentName := range controlPlaneDeploymentNames {
+			deploymentSnapshots = append(deploymentSnapshots, tests.SnapshotDeployment(virtCli, flags.KubeVirtInstallNamespace, deploymentName))
+		}
+		// the eviction and drain tests are only meaningful if the replicas do not share a node
+		for _, deploymentName := range controlPlaneDeploymentNames {
+			tests.AssertPodAntiAffinitySpread(virtCli, flags.KubeVirtInstallNamespace, deploymentName)
+		}
 	})
 
-	Context("pod eviction", func() {
-		var nodeList []k8sv1.Node
+	AfterEach(func() {
+		// restore the deployments even if a test failed half way through mutating them
+		for _, snapshot := range deploymentSnapshots {
+			tests.RestoreDeployment(virtCli, snapshot)
+		}
+	})
 
-		getRunningReadyPods := func(podList *k8sv1.PodList, podNames []string, nodeNames ...string) (pods []*k8sv1.Pod) {
-			pods = make([]*k8sv1.Pod, 0)
-			for _, pod := range podList.Items {
-				if pod.Status.Phase != k8sv1.PodRunning {
-					continue
-				}
+	getPodList := func() (podList *k8sv1.PodList, err error) {
+		podList, err = virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).List(context.Background(), metav1.ListOptions{})
+		return
+	}
 
-				if success, err := matcher.HaveConditionTrue(k8sv1.PodReady).Match(pod); !success {
-					Expect(err).ToNot(HaveOccurred())
-					continue
-				}
+	waitForDeploymentsToStabilize := func() (bool, error) {
+		deploymentsClient := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace)
+		for _, deploymentName := range controlPlaneDeploymentNames {
+			deployment, err := deploymentsClient.Get(context.Background(), deploymentName, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
 
-				for _, podName := range podNames {
-					if strings.HasPrefix(pod.Name, podName) {
-						if len(nodeNames) > 0 {
-							for _, nodeName := range nodeNames {
-								if pod.Spec.NodeName == nodeName {
-									deepCopy := pod.DeepCopy()
-									pods = append(pods, deepCopy)
-								}
-							}
-						} else {
-							deepCopy := pod.DeepCopy()
-							pods = append(pods, deepCopy)
-						}
-					}
-				}
+			if !(deployment.Status.UpdatedReplicas == *(deployment.Spec.Replicas) &&
+				deployment.Status.Replicas == *(deployment.Spec.Replicas) &&
+				deployment.Status.AvailableReplicas == *(deployment.Spec.Replicas)) {
+				return false, err
 			}
-			return
 		}
+		return true, nil
+	}
 
-		getPodList := func() (podList *k8sv1.PodList, err error) {
-			podList, err = virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).List(context.Background(), metav1.ListOptions{})
-			return
-		}
+	eventuallyWithTimeout := func(f func() (bool, error)) {
+		Eventually(f,
+			flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds,
+		).Should(BeTrue())
+	}
 
-		waitForDeploymentsToStabilize := func() (bool, error) {
-			deploymentsClient := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace)
-			for _, deploymentName := range controlPlaneDeploymentNames {
-				deployment, err := deploymentsClient.Get(context.Background(), deploymentName, metav1.GetOptions{})
-				if err != nil {
-					return false, err
-				}
+	getRunningReadyPods := func(podPrefixes []string, nodeNames ...string) []*k8sv1.Pod {
+		podList, err := getPodList()
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		return tests.FilterRunningReadyPods(podList, podPrefixes, nodeNames...)
+	}
 
-				if !(deployment.Status.UpdatedReplicas == *(deployment.Spec.Replicas) &&
-					deployment.Status.Replicas == *(deployment.Spec.Replicas) &&
-					deployment.Status.AvailableReplicas == *(deployment.Spec.Replicas)) {
-					return false, err
-				}
-			}
-			return true, nil
-		}
+	getSelectedNode := func() string {
+		runningPods := getRunningReadyPods(controlPlaneDeploymentNames)
+		Expect(runningPods).ToNot(BeEmpty(), "no running control plane pods found")
+		return runningPods[0].Spec.NodeName
+	}
 
-		eventuallyWithTimeout := func(f func() (bool, error)) {
-			Eventually(f,
-				DefaultStabilizationTimeoutInSeconds, DefaultPollIntervalInSeconds,
-			).Should(BeTrue())
-		}
+	Context("pod eviction", func() {
+		var nodeList []k8sv1.Node
 
 		BeforeEach(func() {
-			nodeList = libnode.GetAllSchedulableNodes(virtCli).Items
+			nodeList = schedulableNodes.GetAllSchedulableNodes().Items
 			for _, node := range nodeList {
 				libnode.SetNodeUnschedulable(node.Name, virtCli)
 			}
@@ -156,21 +166,11 @@ var _ = Describe("[Serial][ref_id:2717][sig-compute]KubeVirt control plane resil
 				checks.SkipIfMultiReplica(virtCli)
 			}
 			By(fmt.Sprintf("Try to evict all pods %s\n", podName))
-			podList, err := getPodList()
-			Expect(err).ToNot(HaveOccurred())
-			runningPods := getRunningReadyPods(podList, []string{podName})
-			Expect(runningPods).ToNot(BeEmpty())
-			for index, pod := range runningPods {
-				err = virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
-				if index == len(runningPods)-1 {
-					if isMultiReplica {
-						Expect(err).To(HaveOccurred(), msg)
-					} else {
-						Expect(err).ToNot(HaveOccurred(), msg)
-					}
-				} else {
-					Expect(err).ToNot(HaveOccurred())
-				}
+			err := tests.EvictAllButAssertLast(virtCli, flags.KubeVirtInstallNamespace, podName)
+			if isMultiReplica {
+				tests.ExpectEvictionRejectedByPDB(err)
+			} else {
+				Expect(err).ToNot(HaveOccurred(), msg)
 			}
 		},
 			Entry("[test_id:2830]last eviction should fail for multi-replica virt-controller pods",
@@ -182,6 +182,600 @@ var _ = Describe("[Serial][ref_id:2717][sig-compute]KubeVirt control plane resil
 			Entry("eviction of multi-replica virt-api pod should succeed",
 				"virt-api", singleReplica, "error occurred on eviction of single-replica virt-api pod"),
 		)
+
+		DescribeTable("evicting pods of control plane as far as the PDB allows", func(deploymentName string, bySelector bool) {
+			checks.SkipIfSingleReplica(virtCli)
+			deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			minAvailable, err := tests.PDBMinAvailableAbsolute(virtCli, flags.KubeVirtInstallNamespace, deploymentName+"-pdb", *deployment.Spec.Replicas)
+			Expect(err).ToNot(HaveOccurred())
+
+			var results []tests.EvictionResult
+			if bySelector {
+				By("Evicting the pods matching the pod template labels of " + deploymentName)
+				results = tests.EvictPodsMatchingSelector(virtCli, flags.KubeVirtInstallNamespace, labels.SelectorFromSet(deployment.Spec.Template.Labels))
+			} else {
+				By("Evicting the pods named after " + deploymentName)
+				for _, pod := range getRunningReadyPods([]string{deploymentName}) {
+					err := virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+					results = append(results, tests.EvictionResult{PodName: pod.Name, Err: err})
+				}
+			}
+			Expect(results).ToNot(BeEmpty(), "no running %s pods found", deploymentName)
+
+			allowedEvictions := len(results) - int(minAvailable)
+			By(fmt.Sprintf("Expecting %d of %d evictions to succeed", allowedEvictions, len(results)))
+			for i, result := range results {
+				if i < allowedEvictions {
+					Expect(result.Err).ToNot(HaveOccurred(), "the PDB should allow the eviction of pod %s", result.PodName)
+				} else {
+					By("Expecting the PDB to reject the eviction of pod " + result.PodName)
+					tests.ExpectEvictionRejectedByPDB(result.Err)
+				}
+			}
+
+			By(fmt.Sprintf("Watching deployment %s for excess pods while it recovers", deploymentName))
+			tests.AssertNoReplicaOvershoot(virtCli, flags.KubeVirtInstallNamespace, deploymentName, recoveryWindow)
+		},
+			Entry("virt-controller", "virt-controller", false),
+			Entry("virt-api", "virt-api", false),
+			Entry("virt-controller by its pod template labels", "virt-controller", true),
+			Entry("virt-api by its pod template labels", "virt-api", true),
+		)
+
+		It("should not evict pods below the PDB floor when evictions are requested concurrently", func() {
+			checks.SkipIfSingleReplica(virtCli)
+
+			type evictionResult struct {
+				deployment string
+				pod        string
+				err        error
+			}
+			deploymentNames := []string{"virt-controller", "virt-api"}
+			minAvailable := map[string]int{}
+			var pods []*k8sv1.Pod
+			podDeployments := map[string]string{}
+			for _, deploymentName := range deploymentNames {
+				deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				floor, err := tests.PDBMinAvailableAbsolute(virtCli, flags.KubeVirtInstallNamespace, deploymentName+"-pdb", *deployment.Spec.Replicas)
+				Expect(err).ToNot(HaveOccurred())
+				minAvailable[deploymentName] = int(floor)
+
+				deploymentPods := getRunningReadyPods([]string{deploymentName})
+				Expect(deploymentPods).ToNot(BeEmpty(), "no running %s pods found", deploymentName)
+				for _, pod := range deploymentPods {
+					podDeployments[pod.Name] = deploymentName
+				}
+				pods = append(pods, deploymentPods...)
+			}
+
+			By(fmt.Sprintf("Evicting %d control plane pods concurrently", len(pods)))
+			results := make(chan evictionResult, len(pods))
+			var wg sync.WaitGroup
+			for _, pod := range pods {
+				wg.Add(1)
+				go func(pod *k8sv1.Pod) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					err := virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+					results <- evictionResult{deployment: podDeployments[pod.Name], pod: pod.Name, err: err}
+				}(pod)
+			}
+			wg.Wait()
+			close(results)
+
+			evicted := map[string]int{}
+			running := map[string]int{}
+			var unexpectedErrs []error
+			for _, pod := range pods {
+				running[podDeployments[pod.Name]]++
+			}
+			for result := range results {
+				switch {
+				case result.err == nil:
+					evicted[result.deployment]++
+				case !errors.IsTooManyRequests(result.err):
+					unexpectedErrs = append(unexpectedErrs, fmt.Errorf("eviction of pod %s failed: %v", result.pod, result.err))
+				}
+			}
+			Expect(utilerrors.NewAggregate(unexpectedErrs)).ToNot(HaveOccurred(), "only PDB rejections are expected")
+
+			for _, deploymentName := range deploymentNames {
+				Expect(running[deploymentName]-evicted[deploymentName]).To(BeNumerically(">=", minAvailable[deploymentName]),
+					"%d of %d %s pods were evicted concurrently, dropping below the PDB floor of %d",
+					evicted[deploymentName], running[deploymentName], deploymentName, minAvailable[deploymentName])
+			}
+		})
+	})
+
+	Context("voluntary scale-down", func() {
+		const deploymentName = "virt-api"
+
+		var originalReplicas int32
+		var originalInfraReplicas *uint8
+
+		// virt-operator reconciles the replicas of its deployments, so they are scaled through the KubeVirt CR
+		setInfraReplicas := func(replicas *uint8) {
+			Eventually(func() error {
+				kv := util.GetCurrentKv(virtCli)
+				if kv.Spec.Infra == nil {
+					kv.Spec.Infra = &k6sv1.ComponentConfig{}
+				}
+				kv.Spec.Infra.Replicas = replicas
+				_, err := virtCli.KubeVirt(kv.Namespace).Update(kv)
+				return err
+			}, 30*time.Second, time.Second).Should(Succeed(), "failed to set the infra replicas of the KubeVirt CR")
+		}
+
+		BeforeEach(func() {
+			originalReplicas = 0
+			originalInfraReplicas = nil
+			if infra := util.GetCurrentKv(virtCli).Spec.Infra; infra != nil && infra.Replicas != nil {
+				replicas := *infra.Replicas
+				originalInfraReplicas = &replicas
+			}
+		})
+
+		AfterEach(func() {
+			if originalReplicas == 0 {
+				return
+			}
+			By(fmt.Sprintf("Restoring the infra replicas of the KubeVirt CR and %d replicas of deployment %s", originalReplicas, deploymentName))
+			setInfraReplicas(originalInfraReplicas)
+			Expect(tests.WaitForDeploymentReplicas(virtCli, flags.KubeVirtInstallNamespace, deploymentName,
+				originalReplicas, time.Duration(flags.StabilizationTimeoutInSeconds)*time.Second)).To(Succeed())
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		It("should allow scaling down while the PDB still rejects evictions at the floor", func() {
+			checks.SkipIfSingleReplica(virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+
+			deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			if *deployment.Spec.Replicas < 2 {
+				Skip(fmt.Sprintf("deployment %s has a single replica which can not be scaled down", deploymentName))
+			}
+			originalReplicas = *deployment.Spec.Replicas
+			replicas := originalReplicas - 1
+
+			By(fmt.Sprintf("Scaling deployment %s down from %d to %d replicas through the KubeVirt CR", deploymentName, originalReplicas, replicas))
+			infraReplicas := uint8(replicas)
+			setInfraReplicas(&infraReplicas)
+			Expect(tests.WaitForDeploymentReplicas(virtCli, flags.KubeVirtInstallNamespace, deploymentName,
+				replicas, time.Duration(flags.StabilizationTimeoutInSeconds)*time.Second)).To(Succeed())
+
+			minAvailable, err := tests.PDBMinAvailableAbsolute(virtCli, flags.KubeVirtInstallNamespace, deploymentName+"-pdb", replicas)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Evicting the remaining pods of " + deploymentName)
+			results := tests.EvictPodsMatchingSelector(virtCli, flags.KubeVirtInstallNamespace, labels.SelectorFromSet(deployment.Spec.Template.Labels))
+			allowedEvictions := len(results) - int(minAvailable)
+			Expect(allowedEvictions).To(BeNumerically("<", len(results)), "the PDB of deployment %s does not protect any replica", deploymentName)
+			for i, result := range results {
+				if i < allowedEvictions {
+					Expect(result.Err).ToNot(HaveOccurred(), "the PDB should allow the eviction of pod %s", result.PodName)
+				} else {
+					By("Expecting the PDB to reject the eviction of pod " + result.PodName)
+					tests.ExpectEvictionRejectedByPDB(result.Err)
+				}
+			}
+		})
+	})
+
+	Context("pod disruption budget", func() {
+		intOrString := func(value intstr.IntOrString) *intstr.IntOrString {
+			return &value
+		}
+
+		DescribeTable("should resolve the minAvailable as an absolute number", func(minAvailable, maxUnavailable *intstr.IntOrString, totalReplicas, expected int32) {
+			namespace := testsuite.GetTestNamespace(nil)
+			pdb := &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "control-plane-test-"},
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					MinAvailable:   minAvailable,
+					MaxUnavailable: maxUnavailable,
+					Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"kubevirt.io/control-plane-test": "pdb"}},
+				},
+			}
+			pdb, err := virtCli.PolicyV1().PodDisruptionBudgets(namespace).Create(context.Background(), pdb, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tests.PDBMinAvailableAbsolute(virtCli, namespace, pdb.Name, totalReplicas)).To(Equal(expected))
+		},
+			Entry("with an absolute minAvailable", intOrString(intstr.FromInt(2)), nil, int32(3), int32(2)),
+			Entry("with a minAvailable percentage rounded up", intOrString(intstr.FromString("50%")), nil, int32(3), int32(2)),
+			Entry("with an exact minAvailable percentage", intOrString(intstr.FromString("50%")), nil, int32(4), int32(2)),
+			Entry("with a minAvailable percentage slightly above a replica", intOrString(intstr.FromString("34%")), nil, int32(3), int32(2)),
+			Entry("with a tiny minAvailable percentage", intOrString(intstr.FromString("1%")), nil, int32(3), int32(1)),
+			Entry("with a zero minAvailable percentage", intOrString(intstr.FromString("0%")), nil, int32(3), int32(0)),
+			Entry("with a full minAvailable percentage", intOrString(intstr.FromString("100%")), nil, int32(2), int32(2)),
+			Entry("with an absolute maxUnavailable", nil, intOrString(intstr.FromInt(1)), int32(3), int32(2)),
+			Entry("with a maxUnavailable percentage rounded up", nil, intOrString(intstr.FromString("50%")), int32(3), int32(1)),
+			Entry("with a full maxUnavailable percentage", nil, intOrString(intstr.FromString("100%")), int32(3), int32(0)),
+		)
+	})
+
+	Context("pod deletion", func() {
+
+		BeforeEach(func() {
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		AfterEach(func() {
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		It("should replace a deleted virt-controller pod", func() {
+			deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), "virt-controller", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			runningPods := getRunningReadyPods([]string{"virt-controller"})
+			Expect(runningPods).ToNot(BeEmpty(), "no running virt-controller pods found")
+			deletedPod := runningPods[0]
+
+			// Unlike an eviction, the deletion bypasses the PDB
+			By(fmt.Sprintf("Deleting the virt-controller pod %s", deletedPod.Name))
+			err = virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).Delete(context.Background(), deletedPod.Name, metav1.DeleteOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Waiting for a replacement pod to become ready")
+			Eventually(func() []string {
+				var names []string
+				for _, pod := range getRunningReadyPods([]string{"virt-controller"}) {
+					if pod.UID != deletedPod.UID {
+						names = append(names, pod.Name)
+					}
+				}
+				return names
+			}, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(
+				HaveLen(int(*deployment.Spec.Replicas)), "the deleted pod %s was not replaced", deletedPod.Name)
+
+			By("Waiting for the control plane to recover")
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+	})
+
+	Context("node drain", func() {
+		var selectedNode string
+
+		BeforeEach(func() {
+			selectedNode = ""
+			if len(schedulableNodes.GetAllSchedulableNodes().Items) < 2 {
+				Skip("Skip node drain test that requires at least two schedulable nodes")
+			}
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+			selectedNode = getSelectedNode()
+		})
+
+		AfterEach(func() {
+			if selectedNode == "" {
+				return
+			}
+			libnode.SetNodeSchedulable(selectedNode, virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		drainSelectedNode := func() {
+			By(fmt.Sprintf("Cordoning node %s", selectedNode))
+			libnode.SetNodeUnschedulable(selectedNode, virtCli)
+
+			By(fmt.Sprintf("Evicting all control plane pods on node %s", selectedNode))
+			podList, err := getPodList()
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			runningPods := tests.FilterRunningReadyPods(podList, controlPlaneDeploymentNames, selectedNode)
+			ExpectWithOffset(1, runningPods).ToNot(BeEmpty())
+			for _, pod := range runningPods {
+				// The PDB may reject the eviction until the previously evicted pod was rescheduled elsewhere
+				EventuallyWithOffset(1, func() error {
+					return virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+				}, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(Succeed(), fmt.Sprintf("failed to evict pod %s", pod.Name))
+			}
+		}
+
+		It("should keep the control plane available while draining a node", func() {
+			drainSelectedNode()
+
+			By("Waiting for the control plane to recover on the remaining nodes")
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+			podList, err := getPodList()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tests.FilterRunningReadyPods(podList, controlPlaneDeploymentNames, selectedNode)).To(BeEmpty(),
+				"no control plane pods are expected to run on the drained node")
+		})
+
+		It("should schedule the control plane pods again after the node is uncordoned", func() {
+			drainSelectedNode()
+
+			By(fmt.Sprintf("Uncordoning node %s", selectedNode))
+			libnode.SetNodeSchedulable(selectedNode, virtCli)
+
+			By("Waiting for the updated replicas of the control plane deployments to match their replicas")
+			for _, deploymentName := range controlPlaneDeploymentNames {
+				deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tests.WaitForDeploymentReplicas(virtCli, flags.KubeVirtInstallNamespace, deploymentName,
+					*deployment.Spec.Replicas, time.Duration(flags.StabilizationTimeoutInSeconds)*time.Second)).To(Succeed())
+			}
+
+			By("Ensuring no control plane pod is stuck pending")
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+			podList, err := getPodList()
+			Expect(err).ToNot(HaveOccurred())
+			for _, pod := range podList.Items {
+				for _, deploymentName := range controlPlaneDeploymentNames {
+					if strings.HasPrefix(pod.Name, deploymentName) {
+						Expect(pod.Status.Phase).ToNot(Equal(k8sv1.PodPending), "pod %s is stuck pending after the uncordon", pod.Name)
+					}
+				}
+			}
+		})
+
+		It("should reschedule the control plane pods of a failed node within the SLA", func() {
+			sla := time.Duration(flags.RescheduleSLAInSeconds) * time.Second
+
+			By(fmt.Sprintf("Cordoning node %s", selectedNode))
+			libnode.SetNodeUnschedulable(selectedNode, virtCli)
+
+			knownPods := map[types.UID]bool{}
+			for _, pod := range getRunningReadyPods(controlPlaneDeploymentNames) {
+				knownPods[pod.UID] = true
+			}
+			failedPods := getRunningReadyPods(controlPlaneDeploymentNames, selectedNode)
+			Expect(failedPods).ToNot(BeEmpty(), "no control plane pods found on node %s", selectedNode)
+
+			By(fmt.Sprintf("Simulating the failure of node %s by deleting its %d control plane pods", selectedNode, len(failedPods)))
+			deletedPerDeployment := map[string]int{}
+			for _, pod := range failedPods {
+				Expect(virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})).To(Succeed())
+				for _, deploymentName := range controlPlaneDeploymentNames {
+					if strings.HasPrefix(pod.Name, deploymentName) {
+						deletedPerDeployment[deploymentName]++
+					}
+				}
+			}
+			start := time.Now()
+
+			By(fmt.Sprintf("Waiting up to %s for ready replacements on other nodes", sla))
+			var missing map[string]int
+			err := wait.PollImmediate(time.Duration(flags.PollIntervalInSeconds)*time.Second, sla, func() (bool, error) {
+				missing = map[string]int{}
+				for deploymentName, deleted := range deletedPerDeployment {
+					replacements := 0
+					for _, pod := range getRunningReadyPods([]string{deploymentName}) {
+						if !knownPods[pod.UID] && pod.Spec.NodeName != selectedNode {
+							replacements++
+						}
+					}
+					if replacements < deleted {
+						missing[deploymentName] = deleted - replacements
+					}
+				}
+				return len(missing) == 0, nil
+			})
+			elapsed := time.Since(start).Round(time.Second)
+			Expect(err).ToNot(HaveOccurred(), "control plane pods were not rescheduled within the SLA of %s, elapsed %s, missing replacements: %v",
+				sla, elapsed, missing)
+			By(fmt.Sprintf("The control plane pods were rescheduled after %s", elapsed))
+		})
+	})
+
+	Context("unrelated pod eviction", func() {
+		var selectedNode string
+		var throwawayPod *k8sv1.Pod
+
+		BeforeEach(func() {
+			// the control plane PDBs only exist for multiple replicas
+			checks.SkipIfSingleReplica(virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+			selectedNode = getSelectedNode()
+
+			pod := tests.RenderPod("throwaway-", []string{"/bin/bash", "-c", "sleep infinity"}, nil)
+			pod.Spec.NodeSelector = map[string]string{k8sv1.LabelHostname: selectedNode}
+			throwawayPod = tests.RunPod(pod)
+		})
+
+		AfterEach(func() {
+			if throwawayPod == nil {
+				return
+			}
+			err := virtCli.CoreV1().Pods(throwawayPod.Namespace).Delete(context.Background(), throwawayPod.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				Expect(err).ToNot(HaveOccurred())
+			}
+			throwawayPod = nil
+		})
+
+		It("should evict the unrelated pod regardless of the control plane PDBs", func() {
+			By("Ensuring the control plane PDBs are active")
+			podDisruptionBudgetList, err := virtCli.PolicyV1().PodDisruptionBudgets(flags.KubeVirtInstallNamespace).List(context.Background(), metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(podDisruptionBudgetList.Items).ToNot(BeEmpty(), "the control plane PDBs are expected to exist")
+
+			By(fmt.Sprintf("Evicting the unrelated pod %s from node %s", throwawayPod.Name, selectedNode))
+			err = virtCli.CoreV1().Pods(throwawayPod.Namespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: throwawayPod.Name}})
+			Expect(err).ToNot(HaveOccurred(), "the control plane PDBs must not block the eviction of unrelated pods")
+
+			Eventually(func() error {
+				_, err := virtCli.CoreV1().Pods(throwawayPod.Namespace).Get(context.Background(), throwawayPod.Name, metav1.GetOptions{})
+				return err
+			}, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(Satisfy(errors.IsNotFound), "the evicted pod %s should be deleted", throwawayPod.Name)
+		})
+	})
+
+	Context("leader election", func() {
+
+		getLeader := func() (string, error) {
+			return tests.GetLeaderPodName(virtCli, flags.KubeVirtInstallNamespace, leaderelectionconfig.DefaultEndpointName)
+		}
+
+		BeforeEach(func() {
+			checks.SkipIfSingleReplica(virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		AfterEach(func() {
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		It("should elect a new virt-controller leader when the leader is evicted", func() {
+			By("Identifying the current virt-controller leader")
+			leader, err := getLeader()
+			Expect(err).ToNot(HaveOccurred(), "failed to identify the virt-controller leader")
+
+			By(fmt.Sprintf("Evicting the leader pod %s", leader))
+			Eventually(func() error {
+				return virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: leader}})
+			}, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(Succeed(), fmt.Sprintf("failed to evict pod %s", leader))
+
+			By("Waiting for a standby virt-controller to take over the lease")
+			Eventually(getLeader, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(
+				Not(Equal(leader)), "no new virt-controller leader was elected")
+
+			By("Waiting for the control plane to recover")
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+	})
+
+	Context("graceful eviction", func() {
+		// deletionPollInterval is the resolution the pod deletion time is measured with
+		const deletionPollInterval = 100 * time.Millisecond
+		// deletionSlack is the time kubelet may need beyond the grace period to kill and remove the pod
+		const deletionSlack = 30 * time.Second
+
+		BeforeEach(func() {
+			checks.SkipIfSingleReplica(virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		AfterEach(func() {
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		It("should respect the termination grace period of an evicted virt-controller pod", func() {
+			deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), "virt-controller", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			runningPods := getRunningReadyPods([]string{"virt-controller"})
+			Expect(runningPods).ToNot(BeEmpty(), "no running virt-controller pods found")
+			pod := runningPods[0]
+			Expect(pod.Spec.TerminationGracePeriodSeconds).ToNot(BeNil(), "the pod spec has no termination grace period")
+			gracePeriodSeconds := *pod.Spec.TerminationGracePeriodSeconds
+			gracePeriod := time.Duration(gracePeriodSeconds) * time.Second
+
+			podsClient := virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace)
+			By(fmt.Sprintf("Evicting the virt-controller pod %s", pod.Name))
+			Eventually(func() error {
+				return podsClient.EvictV1beta1(context.Background(), &v1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name}})
+			}, flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(Succeed(), fmt.Sprintf("failed to evict pod %s", pod.Name))
+			evictedAt := time.Now()
+
+			isDeleted := func() bool {
+				current, err := podsClient.Get(context.Background(), pod.Name, metav1.GetOptions{})
+				if errors.IsNotFound(err) {
+					return true
+				}
+				Expect(err).ToNot(HaveOccurred())
+				return current.UID != pod.UID
+			}
+
+			By("Verifying the pod is terminating with the configured grace period")
+			terminating, err := podsClient.Get(context.Background(), pod.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) || (err == nil && terminating.UID != pod.UID) {
+				Skip("the evicted pod was deleted faster than measurable")
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(terminating.DeletionTimestamp).ToNot(BeNil(), "the evicted pod is not terminating")
+			Expect(terminating.DeletionGracePeriodSeconds).To(HaveValue(Equal(gracePeriodSeconds)),
+				"the evicted pod is not deleted with its termination grace period")
+
+			By("Measuring the time until the pod is deleted")
+			Eventually(isDeleted, gracePeriod+deletionSlack, deletionPollInterval).Should(BeTrue(),
+				fmt.Sprintf("pod %s was not deleted within its termination grace period of %v", pod.Name, gracePeriod))
+			elapsed := time.Since(evictedAt)
+			if elapsed < deletionPollInterval {
+				Skip(fmt.Sprintf("the evicted pod was deleted after %v, faster than measurable", elapsed))
+			}
+			Expect(elapsed).To(BeNumerically("<=", gracePeriod+deletionSlack),
+				"the evicted pod outlived its termination grace period")
+
+			sla := time.Duration(flags.RescheduleSLAInSeconds) * time.Second
+			By(fmt.Sprintf("Waiting up to %s for a ready replacement restoring the %d replicas", sla, *deployment.Spec.Replicas))
+			Eventually(func() int {
+				podList, err := getPodList()
+				Expect(err).ToNot(HaveOccurred())
+				replicas := 0
+				for _, running := range tests.FilterRunningReadyPods(podList, []string{"virt-controller"}) {
+					if running.UID != pod.UID {
+						replicas++
+					}
+				}
+				return replicas
+			}, sla, time.Duration(flags.PollIntervalInSeconds)*time.Second).Should(Equal(int(*deployment.Spec.Replicas)),
+				"the evicted pod %s was not replaced by a running and ready pod within %s", pod.Name, sla)
+		})
+	})
+
+	Context("virt-api readiness", func() {
+		const isolationLabel = "kubevirt.io/control-plane-test-isolated"
+
+		var isolatedPod string
+		var policy *networkv1.NetworkPolicy
+
+		BeforeEach(func() {
+			// a single virt-api replica can not tolerate losing its readiness
+			checks.SkipIfSingleReplica(virtCli)
+			// the pod is cut off the apiserver by a network policy, which flannel does not enforce
+			checks.SkipIfUseFlannel(virtCli)
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+			isolatedPod, policy = "", nil
+		})
+
+		AfterEach(func() {
+			if policy != nil {
+				By("Restoring the connectivity of the virt-api pod")
+				err := virtCli.NetworkingV1().NetworkPolicies(flags.KubeVirtInstallNamespace).Delete(context.Background(), policy.Name, metav1.DeleteOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			if isolatedPod != "" {
+				patchData := fmt.Sprintf(`[{"op": "remove", "path": "/metadata/labels/%s"}]`, strings.ReplaceAll(isolationLabel, "/", "~1"))
+				_, err := virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).Patch(context.Background(), isolatedPod, types.JSONPatchType, []byte(patchData), metav1.PatchOptions{})
+				if err != nil && !errors.IsNotFound(err) {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+			eventuallyWithTimeout(waitForDeploymentsToStabilize)
+		})
+
+		It("should report not ready when it can not reach the apiserver", func() {
+			podList, err := getPodList()
+			Expect(err).ToNot(HaveOccurred())
+			runningPods := tests.FilterRunningReadyPods(podList, []string{"virt-api"})
+			Expect(runningPods).ToNot(BeEmpty(), "no running virt-api pods found")
+			isolatedPod = runningPods[0].Name
+
+			By(fmt.Sprintf("Cutting the virt-api pod %s off the apiserver", isolatedPod))
+			patchData := fmt.Sprintf(`{"metadata": {"labels": {%q: "true"}}}`, isolationLabel)
+			_, err = virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).Patch(context.Background(), isolatedPod, types.MergePatchType, []byte(patchData), metav1.PatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			policy, err = virtCli.NetworkingV1().NetworkPolicies(flags.KubeVirtInstallNamespace).Create(context.Background(), &networkv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "deny-virt-api-egress-"},
+				Spec: networkv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{isolationLabel: "true"}},
+					PolicyTypes: []networkv1.PolicyType{networkv1.PolicyTypeEgress},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Waiting for the readiness of the virt-api pod to flip to false")
+			// the readiness probe only checks the apiserver again once the informers lost their connection
+			Eventually(func() (k8sv1.ConditionStatus, error) {
+				pod, err := virtCli.CoreV1().Pods(flags.KubeVirtInstallNamespace).Get(context.Background(), isolatedPod, metav1.GetOptions{})
+				if err != nil {
+					return "", err
+				}
+				return tests.PodReady(pod), nil
+			}, 2*flags.StabilizationTimeoutInSeconds, flags.PollIntervalInSeconds).Should(Equal(k8sv1.ConditionFalse))
+		})
 	})
 
 	Context("control plane components check", func() {
@@ -229,6 +823,54 @@ var _ = Describe("[Serial][ref_id:2717][sig-compute]KubeVirt control plane resil
 				}
 			})
 
+			It("virt-controller and virt-api pods have cpu and memory requests", func() {
+				podList, err := getPodList()
+				Expect(err).ToNot(HaveOccurred())
+				runningPods := tests.FilterRunningReadyPods(podList, controlPlaneDeploymentNames)
+				Expect(runningPods).ToNot(BeEmpty(), "no running control plane pods found")
+
+				var missingRequests []string
+				for _, pod := range runningPods {
+					for _, container := range pod.Spec.Containers {
+						for _, resourceName := range []k8sv1.ResourceName{k8sv1.ResourceCPU, k8sv1.ResourceMemory} {
+							if request, exists := container.Resources.Requests[resourceName]; !exists || request.IsZero() {
+								missingRequests = append(missingRequests, fmt.Sprintf("%s/%s: %s", pod.Name, container.Name, resourceName))
+							}
+						}
+					}
+				}
+				Expect(missingRequests).To(BeEmpty(), "control plane containers are missing resource requests")
+			})
+
+			It("virt-controller and virt-api containers run as non-root", func() {
+				for _, deploymentName := range controlPlaneDeploymentNames {
+					tests.AssertContainersNonRoot(virtCli, flags.KubeVirtInstallNamespace, deploymentName)
+				}
+			})
+
+			It("virt-controller and virt-api containers have a liveness probe", func() {
+				for _, deploymentName := range controlPlaneDeploymentNames {
+					tests.AssertContainersHaveLivenessProbe(virtCli, flags.KubeVirtInstallNamespace, deploymentName)
+				}
+			})
+
+			It("virt-controller and virt-api deployments use a critical priority class", func() {
+				// the highest priority which can be assigned to a priority class which is not a system one
+				const highestUserDefinablePriority = int32(1000000000)
+
+				for _, deploymentName := range controlPlaneDeploymentNames {
+					deployment, err := virtCli.AppsV1().Deployments(flags.KubeVirtInstallNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+					Expect(err).ToNot(HaveOccurred())
+					priorityClassName := deployment.Spec.Template.Spec.PriorityClassName
+					Expect(priorityClassName).ToNot(BeEmpty(), "deployment %s has no priority class", deploymentName)
+
+					priorityClass, err := virtCli.SchedulingV1().PriorityClasses().Get(context.Background(), priorityClassName, metav1.GetOptions{})
+					Expect(err).ToNot(HaveOccurred(), "priority class %s of deployment %s not found", priorityClassName, deploymentName)
+					Expect(priorityClass.Value).To(BeNumerically(">=", highestUserDefinablePriority),
+						"deployment %s uses priority class %s with priority %d, which is not critical", deploymentName, priorityClassName, priorityClass.Value)
+				}
+			})
+
 		})
 
 		When("Control plane pods temporarily lose connection to Kubernetes API", func() {
@@ -169,6 +169,21 @@ func getMetricsNotIncludeInEndpointByDefault() metricList {
 			description: "Histogram of VM phase transitions duration from deletion time in seconds.",
 			mType:       "Histogram",
 		},
+		{
+			name:        "kubevirt_node_missing_required_capability",
+			description: "Indication for a capability required by the cluster CPU policy which the node is missing.",
+			mType:       "Gauge",
+		},
+		{
+			name:        "kubevirt_node_cpu_feature",
+			description: "Indication for a cpu feature the node-labeller labelled on the node.",
+			mType:       "Gauge",
+		},
+		{
+			name:        "kubevirt_nodelabeller_reconcile_total",
+			description: "The total number of node-labeller reconciles by result.",
+			mType:       "Counter",
+		},
 		{
 			name:        "kubevirt_virt_operator_leading_status",
 			description: "Indication for an operating virt-operator.",